@@ -0,0 +1,316 @@
+package eden
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gou-jjjj/eden/translate"
+	"github.com/panjf2000/ants"
+)
+
+var (
+	// DefaultInclude 默认只处理 docx 文件
+	DefaultInclude = []string{"*.docx"}
+	// DefaultExclude 默认忽略 Word 打开文档时生成的临时文件
+	DefaultExclude = []string{"~$*"}
+)
+
+// ErrOutputCollision 多个输入文件对应同一个输出文件
+var ErrOutputCollision = errors.New("output file collision")
+
+// BatchOpt 批量处理选项
+type BatchOpt func(*Batch)
+
+// WithInclude 只处理匹配的文件，不含 / 的模式匹配文件名，** 匹配任意层目录
+func WithInclude(patterns ...string) BatchOpt {
+	return func(b *Batch) {
+		b.include = patterns
+	}
+}
+
+// WithExclude 忽略匹配的文件和目录，规则与 WithInclude 相同
+func WithExclude(patterns ...string) BatchOpt {
+	return func(b *Batch) {
+		b.exclude = append(b.exclude, patterns...)
+	}
+}
+
+// WithFileConcurrency 同时处理的文件数
+func WithFileConcurrency(n int) BatchOpt {
+	return func(b *Batch) {
+		b.fileGo = n
+	}
+}
+
+// WithBatchMaxGo 所有文件共享的翻译协程池大小
+func WithBatchMaxGo(n int) BatchOpt {
+	return func(b *Batch) {
+		b.maxGo = n
+	}
+}
+
+// WithBatchRateLimiter 所有文件共享的限流器
+func WithBatchRateLimiter(limiter *translate.RateLimiter) BatchOpt {
+	return func(b *Batch) {
+		b.limiter = limiter
+	}
+}
+
+// WithDocxOptions 每个文件的处理器选项，输入、输出和协程池由批量处理设置
+func WithDocxOptions(opts ...Opt) BatchOpt {
+	return func(b *Batch) {
+		b.opts = append(b.opts, opts...)
+	}
+}
+
+// Batch 批量翻译目录下的文档，输出目录保持输入目录的结构
+type Batch struct {
+	inputDir  string
+	outputDir string
+	include   []string
+	exclude   []string
+	fileGo    int
+	maxGo     int
+	limiter   *translate.RateLimiter
+	opts      []Opt
+}
+
+// NewBatch 创建批量处理器
+func NewBatch(inputDir, outputDir string, opts ...BatchOpt) *Batch {
+	b := &Batch{
+		inputDir:  inputDir,
+		outputDir: outputDir,
+		include:   DefaultInclude,
+		exclude:   append([]string{}, DefaultExclude...),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(b)
+		}
+	}
+
+	if b.maxGo <= 0 {
+		b.maxGo = 1
+	}
+	if b.fileGo <= 0 {
+		b.fileGo = b.maxGo
+	}
+	return b
+}
+
+// BatchFile 单个文件的处理结果
+type BatchFile struct {
+	Path   string  `json:"path"` // 相对输入目录的路径
+	Report *Report `json:"report,omitempty"`
+	Error  string  `json:"error,omitempty"`
+	Err    error   `json:"-"`
+}
+
+// BatchReport 批量处理汇总报告
+type BatchReport struct {
+	Input        string                 `json:"input"`
+	Output       string                 `json:"output"`
+	Files        []BatchFile            `json:"files"`
+	Succeeded    int                    `json:"succeeded"`     // 处理成功的文件数
+	Failed       int                    `json:"failed"`        // 处理失败的文件数
+	Translated   int                    `json:"translated"`    // 翻译成功的分块数
+	Skipped      int                    `json:"skipped"`       // 跳过的分块数
+	FailedChunks int                    `json:"failed_chunks"` // 翻译失败的分块数
+	Usage        translate.UsageByModel `json:"usage"`
+	Cost         float64                `json:"cost"`
+	Duration     time.Duration          `json:"duration"`
+}
+
+// Err 所有失败文件的错误，没有失败时返回 nil
+func (r *BatchReport) Err() error {
+	errs := make([]error, 0, r.Failed)
+	for _, f := range r.Files {
+		if f.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Path, f.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Files 按路径排序的待处理文件，返回相对输入目录的路径
+func (b *Batch) Files() ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.WalkDir(b.inputDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(b.inputDir, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		if MatchAny(b.exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() || !MatchAny(b.include, rel) {
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// Run 处理所有文件，所有文件的分块共享一个协程池和限流器，有文件失败时返回错误
+func (b *Batch) Run() (*BatchReport, error) {
+	startTime := time.Now()
+	report := &BatchReport{
+		Input:  b.inputDir,
+		Output: b.outputDir,
+		Files:  make([]BatchFile, 0),
+		Usage:  translate.UsageByModel{},
+	}
+
+	files, err := b.Files()
+	if err != nil {
+		return report, err
+	}
+	if err := checkCollisions(files); err != nil {
+		return report, err
+	}
+
+	pool, err := ants.NewPool(b.maxGo,
+		ants.WithMaxBlockingTasks(1<<20),
+		ants.WithPreAlloc(true),
+		ants.WithExpiryDuration(1))
+	if err != nil {
+		return report, err
+	}
+	defer pool.Release()
+
+	results := make([]BatchFile, len(files))
+	sem := make(chan struct{}, b.fileGo)
+	var wg sync.WaitGroup
+	for i, rel := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = b.processFile(rel, pool)
+		}()
+	}
+	wg.Wait()
+
+	for _, res := range results {
+		report.Files = append(report.Files, res)
+		if res.Err != nil {
+			report.Failed++
+		} else {
+			report.Succeeded++
+		}
+		if res.Report != nil {
+			report.Translated += res.Report.Translated
+			report.Skipped += res.Report.Skipped
+			report.FailedChunks += res.Report.Failed
+			report.Usage.Merge(res.Report.Usage)
+			report.Cost += res.Report.Cost
+		}
+	}
+	report.Duration = time.Since(startTime)
+
+	return report, report.Err()
+}
+
+// processFile 处理单个文件，输出到与输入相同的相对目录
+func (b *Batch) processFile(rel string, pool *ants.Pool) BatchFile {
+	opts := append([]Opt{}, b.opts...)
+	opts = append(opts,
+		WithInput(filepath.Join(b.inputDir, rel)),
+		WithOutput(filepath.Join(b.outputDir, filepath.Dir(rel))),
+		WithPool(pool))
+	if b.limiter != nil {
+		opts = append(opts, WithRateLimiter(b.limiter))
+	}
+
+	res := BatchFile{Path: filepath.ToSlash(rel)}
+	res.Report, res.Err = NewDocxProcessor(opts...).Process()
+	if res.Err != nil {
+		res.Error = res.Err.Error()
+	}
+	return res
+}
+
+// checkCollisions 检查输出文件名冲突，同一目录下去掉扩展名后同名的文件会互相覆盖
+// 按不区分大小写比较，兼容大小写不敏感的文件系统
+func checkCollisions(files []string) error {
+	seen := map[string]string{}
+	errs := make([]error, 0)
+	for _, rel := range files {
+		key := strings.ToLower(filepath.ToSlash(filepath.Join(filepath.Dir(rel), outputName(rel))))
+		if prev, ok := seen[key]; ok {
+			errs = append(errs, fmt.Errorf("%w: %s and %s",
+				ErrOutputCollision, filepath.ToSlash(prev), filepath.ToSlash(rel)))
+			continue
+		}
+		seen[key] = rel
+	}
+	return errors.Join(errs...)
+}
+
+// MatchAny 相对路径匹配任意一个模式
+func MatchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchGlob 匹配相对路径，不含 / 的模式只匹配文件名，** 匹配任意层目录
+func MatchGlob(pattern, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments 逐级匹配路径
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
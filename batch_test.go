@@ -0,0 +1,136 @@
+package eden
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/translate"
+)
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"*.docx", "a.docx", true},
+		{"*.docx", "sub/deep/a.docx", true},
+		{"*.docx", "a.txt", false},
+		{"sub/*.docx", "sub/a.docx", true},
+		{"sub/*.docx", "sub/deep/a.docx", false},
+		{"sub/**/*.docx", "sub/a.docx", true},
+		{"sub/**/*.docx", "sub/deep/a.docx", true},
+		{"**/drafts/**", "x/drafts/a.docx", true},
+		{"archive/**", "archive", true},
+		{"~$*", "sub/~$a.docx", true},
+	}
+	for _, c := range cases {
+		if got := MatchGlob(c.pattern, c.rel); got != c.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", c.pattern, c.rel, got, c.want)
+		}
+	}
+}
+
+func TestBatch_OutputCollision(t *testing.T) {
+	in := t.TempDir()
+	for _, name := range []string{"a.b.docx", "a.c.docx", "x.docx", "sub/x.docx", "sub/X.DOCX"} {
+		p := filepath.Join(in, name)
+		if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("not a docx"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tran := &stubTran{}
+	b := NewBatch(in, t.TempDir(),
+		WithInclude("*.docx", "*.DOCX"),
+		WithDocxOptions(WithLang(lang.ZH), WithProcessFunc(tran), WithLogWriter(io.Discard)))
+	_, err := b.Run()
+	if !errors.Is(err, ErrOutputCollision) {
+		t.Fatalf("Expected ErrOutputCollision, got %v", err)
+	}
+	if msg := err.Error(); strings.Contains(msg, "a.b.docx") || !strings.Contains(msg, "sub/X.DOCX") {
+		t.Errorf("Unexpected collisions %q", msg)
+	}
+	if tran.calls.Load() != 0 {
+		t.Error("Expected no file to be processed")
+	}
+}
+
+func TestBatch_Run(t *testing.T) {
+	src, err := os.ReadFile("./file_examples/Docx4j_GettingStarted.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := t.TempDir()
+	out := t.TempDir()
+	files := map[string][]byte{
+		"a.docx":            src,
+		"sub/b.docx":        src,
+		"sub/~$b.docx":      src,
+		"sub/notes.txt":     []byte("notes"),
+		"drafts/c.docx":     src,
+		"sub/deep/bad.docx": []byte("not a docx"),
+	}
+	for name, data := range files {
+		p := filepath.Join(in, name)
+		if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tran := &stubTran{}
+	b := NewBatch(in, out,
+		WithExclude("drafts"),
+		WithBatchMaxGo(4),
+		WithFileConcurrency(2),
+		WithBatchRateLimiter(translate.NewRateLimiter(1000, 100)),
+		WithDocxOptions(
+			WithLang(lang.ZH),
+			WithProcessFunc(tran),
+			WithMaxToken(200),
+			WithLogWriter(io.Discard)))
+
+	rels, err := b.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 3 || rels[0] != "a.docx" || rels[1] != filepath.Join("sub", "b.docx") {
+		t.Fatalf("Unexpected files %v", rels)
+	}
+
+	report, err := b.Run()
+	if err == nil {
+		t.Fatal("Expected error for invalid docx")
+	}
+	if report.Succeeded != 2 || report.Failed != 1 {
+		t.Errorf("Expected 2 succeeded and 1 failed, got %d/%d", report.Succeeded, report.Failed)
+	}
+	if report.Files[2].Path != "sub/deep/bad.docx" || report.Files[2].Error == "" {
+		t.Errorf("Unexpected failed file %+v", report.Files[2])
+	}
+	if report.Translated == 0 || int(tran.calls.Load()) != report.Translated {
+		t.Errorf("Expected %d calls, got %d", report.Translated, tran.calls.Load())
+	}
+	if errors.Is(err, ErrTooManyFailures) {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	// 输出目录保持输入目录的结构
+	for _, name := range []string{"a_中文.docx", filepath.Join("sub", "b_中文.docx")} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Errorf("Expected output %s: %v", name, err)
+		}
+	}
+}
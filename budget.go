@@ -0,0 +1,108 @@
+package eden
+
+import (
+	"github.com/gou-jjjj/eden/tokenizer"
+	"github.com/gou-jjjj/eden/translate"
+)
+
+const (
+	// DefaultSummaryTokens 文档摘要最多占用的 token 数，更长的摘要会被截断
+	DefaultSummaryTokens = 300
+	// DefaultContextTokens 上下文窗口最多占用的 token 数，超出时丢弃较早的片段
+	DefaultContextTokens = 1000
+)
+
+// chunkBudget 计算单个分块可用的原文 token 数，按所有目标语言中最大的请求开销计算
+func (p *DocxProcessor) chunkBudget() int {
+	targets := p.targets
+	if len(targets) == 0 {
+		targets = []*target{{to: p.toLang}}
+	}
+
+	overhead := 0
+	for _, tg := range targets {
+		overhead = max(overhead, p.overheadTokens(tg, -1))
+	}
+	budget := tokenizer.ChunkBudget(tokenizer.LimitFor(p.model), overhead, p.expansion)
+	if p.maxToken > 0 && p.maxToken < budget {
+		budget = p.maxToken
+	}
+	return budget
+}
+
+// overheadTokens 请求中原文以外的 token 数，包括翻译器渲染的提示词、少样本示例、术语表、风格和片段说明，
+// 以及摘要和上下文窗口的预留
+// idx 小于 0 时按最坏情况计算：带完整术语表，摘要和上下文窗口按上限预留；片段说明由分块时逐个计入
+func (p *DocxProcessor) overheadTokens(tg *target, idx int) int {
+	req := &translate.TranReq{
+		From:     p.fromLang,
+		To:       tg.to,
+		Paras:    make(translate.Paragraph, 2),
+		Glossary: p.glossary,
+	}
+	if idx >= 0 {
+		req.From = p.fromFor(idx)
+		req.Paras = p.paraSet[idx]
+		req.Glossary = p.glossaryFor(req.Paras)
+		req.Segments = p.segmentsFor(idx)
+	}
+	p.styleReq(tg, req)
+
+	summary, context := p.summaryReserve(), p.contextReserve(idx)
+	if summary+context > 0 {
+		// 只渲染上下文的标题，内容按预留计算
+		req.Context = &translate.DocContext{Summary: "-", Previous: []translate.Pair{{Source: "-"}}}
+	}
+	return p.tokenizer.Count(translate.PromptFor(p.process, req)) + summary + context
+}
+
+// summaryReserve 摘要占用的 token 数，摘要尚未生成时按上限预留
+func (p *DocxProcessor) summaryReserve() int {
+	if p.summary != "" {
+		return p.tokenizer.Count(p.summary)
+	}
+	if p.summarizer != nil {
+		return DefaultSummaryTokens
+	}
+	return 0
+}
+
+// contextReserve 上下文窗口占用的 token 数，idx 小于 0 时按上限预留，
+// 否则按分块之前的原文估算，译文按膨胀系数计入
+func (p *DocxProcessor) contextReserve(idx int) int {
+	if p.contextWindow <= 0 {
+		return 0
+	}
+	if idx < 0 {
+		return DefaultContextTokens
+	}
+
+	tokens, n := 0, 0
+	for i := idx - 1; i >= 0 && n < p.contextWindow; i-- {
+		para := p.paraSet[i]
+		for j := len(para) - 1; j >= 0 && n < p.contextWindow; j-- {
+			tokens += int(float64(p.tokenizer.Count(para[j])) * (1 + p.expansion))
+			n++
+		}
+	}
+	return min(tokens, DefaultContextTokens)
+}
+
+// truncateTokens 截断文本，使其不超过 n 个 token
+func (p *DocxProcessor) truncateTokens(s string, n int) string {
+	if p.tokenizer.Count(s) <= n {
+		return s
+	}
+
+	runes := []rune(s)
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if p.tokenizer.Count(string(runes[:mid])) <= n {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return string(runes[:lo])
+}
@@ -0,0 +1,136 @@
+package eden
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gou-jjjj/eden/translate"
+)
+
+// checkpointEntry 检查点文件中的一行，记录一个已完成的分块
+type checkpointEntry struct {
+	ID     string              `json:"id"`
+	Source translate.Paragraph `json:"source"`
+	Target translate.Paragraph `json:"target"`
+}
+
+// checkpoint 翻译进度检查点，已完成的分块逐行追加到文件中
+type checkpoint struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+	done map[string]translate.Paragraph
+}
+
+// docHash 计算文档内容和翻译参数的哈希，作为检查点文件名
+func docHash(r io.Reader, params ...string) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	for _, param := range params {
+		h.Write([]byte{0})
+		h.Write([]byte(param))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// chunkID 分块标识，由序号和内容哈希组成，分块大小变化后不会误用旧结果
+func chunkID(index int, para translate.Paragraph) string {
+	sum := sha256.Sum256([]byte(strings.Join(para, translate.Seq)))
+	return fmt.Sprintf("%d-%s", index, hex.EncodeToString(sum[:8]))
+}
+
+// openCheckpoint 打开或创建检查点文件，并加载已完成的分块
+func openCheckpoint(dir, hash string) (*checkpoint, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("创建检查点目录失败: %w", err)
+	}
+
+	c := &checkpoint{
+		path: filepath.Join(dir, hash+".ckpt.jsonl"),
+		done: map[string]translate.Paragraph{},
+	}
+
+	if f, err := os.Open(c.path); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 1<<16), 1<<26)
+		for scanner.Scan() {
+			var entry checkpointEntry
+			// 进程中断时最后一行可能不完整，直接忽略
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				continue
+			}
+			c.done[entry.ID] = entry.Target
+		}
+		_ = f.Close()
+	}
+
+	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开检查点文件失败: %w", err)
+	}
+	c.file = f
+	return c, nil
+}
+
+// len 已完成的分块数
+func (c *checkpoint) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.done)
+}
+
+// get 获取已完成分块的译文
+func (c *checkpoint) get(id string) (translate.Paragraph, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.done[id]
+	return t, ok
+}
+
+// save 记录一个已完成的分块并立即落盘
+func (c *checkpoint) save(id string, source, target translate.Paragraph) error {
+	line, err := json.Marshal(checkpointEntry{ID: id, Source: source, Target: target})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.done[id] = target
+	if c.file == nil {
+		return os.ErrClosed
+	}
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return c.file.Sync()
+}
+
+// close 关闭检查点文件，可以重复调用
+func (c *checkpoint) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// remove 任务完成后删除检查点文件
+func (c *checkpoint) remove() error {
+	_ = c.close()
+	return os.Remove(c.path)
+}
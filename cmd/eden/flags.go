@@ -0,0 +1,381 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gou-jjjj/eden"
+	"github.com/gou-jjjj/eden/config"
+)
+
+// options 命令行选项，与 eden.Opt 对应
+type options struct {
+	config     string
+	output     string
+	from       string
+	to         string
+	provider   string
+	model      string
+	tokenizer  string
+	maxGo      int
+	maxToken   int
+	expansion  float64
+	latency    time.Duration
+	strict     bool
+	segment    bool
+	summary    bool
+	prompt     string
+	examples   string
+	context    int
+	domain     string
+	tone       string
+	formality  string
+	locale     string
+	audience   string
+	maxFail    float64
+	checkpoint string
+	rate       float64
+	burst      int
+	include    string
+	exclude    string
+	fileGo     int
+	timeout    time.Duration
+	proxy      string
+	retries    int
+	jobID      string
+	labels     string
+	reqModel   string
+	temp       float64
+	maxOutput  int
+	detect     string
+	detectConf float64
+	checkLang  bool
+	log        string
+	report     string
+	quiet      bool
+	json       bool
+}
+
+// defaultOptions 命令的默认选项，只有 translate 和 watch 默认写日志文件
+func defaultOptions(cmd string) *options {
+	o := &options{
+		output:  "out",
+		from:    "all",
+		to:      "en",
+		maxGo:   4,
+		burst:   1,
+		retries: -1,
+		log:     "none",
+	}
+	if cmd == "translate" || cmd == "watch" {
+		o.log = "file"
+	}
+	return o
+}
+
+// newFlagSet 注册命令的所有选项
+func newFlagSet(name string, o *options, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: eden %s [flags] <file|glob|dir>...\n\nFlags:\n", name)
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&o.config, "config", o.config, "YAML, JSON or TOML job profile, flags on the command line take precedence")
+	fs.StringVar(&o.output, "o", o.output, "output directory, directories are mirrored below it")
+	fs.StringVar(&o.from, "from", o.from, "source language code, all detects the language of each document and chunk")
+	fs.StringVar(&o.to, "to", o.to, "comma separated target language codes, one output file per language")
+	fs.StringVar(&o.provider, "provider", o.provider, "translator, see 'eden providers'; required by translate")
+	fs.StringVar(&o.model, "model", o.model, "model used for tokenizer, context limits and prices (default: provider model)")
+	fs.StringVar(&o.tokenizer, "tokenizer", o.tokenizer, "tokenizer: rune, estimate or tiktoken (default: chosen by model)")
+	fs.IntVar(&o.maxGo, "max-go", o.maxGo, "maximum concurrent translation requests")
+	fs.IntVar(&o.maxToken, "max-token", o.maxToken, "maximum source tokens per chunk (default: derived from the model)")
+	fs.Float64Var(&o.expansion, "expansion", o.expansion, "expected target/source token ratio (default 1.5)")
+	fs.DurationVar(&o.latency, "latency", o.latency, "request latency used for estimates (default: derived from output tokens)")
+	fs.BoolVar(&o.strict, "strict", o.strict, "fail and do not save a file when any chunk fails")
+	fs.StringVar(&o.prompt, "prompt", o.prompt, "prompt template file, see the prompt package for the variables")
+	fs.StringVar(&o.examples, "examples", o.examples, "YAML or JSON file of few-shot examples per language pair")
+	fs.BoolVar(&o.summary, "summary", o.summary, "summarize the document first and send the summary with every request")
+	fs.IntVar(&o.context, "context", o.context, "send this many preceding segments and their translations with every request")
+	fs.StringVar(&o.domain, "domain", o.domain, "document domain: legal, medical, technical, marketing or your own")
+	fs.StringVar(&o.tone, "tone", o.tone, "tone of the translation, e.g. friendly or persuasive")
+	fs.StringVar(&o.formality, "formality", o.formality, "formality of the translation: formal or informal")
+	fs.StringVar(&o.locale, "locale", o.locale, "comma separated target locales, e.g. zh-TW,en-GB, matched to -to by language")
+	fs.StringVar(&o.audience, "audience", o.audience, "intended audience of the translation, e.g. developers")
+	fs.BoolVar(&o.segment, "segment", o.segment, "split text into sentences, sentences spanning runs are translated as a whole")
+	fs.Float64Var(&o.maxFail, "max-fail-ratio", o.maxFail, "fail and do not save a file when more than this ratio of chunks fails")
+	fs.StringVar(&o.checkpoint, "checkpoint", o.checkpoint, "directory for checkpoints, interrupted runs resume from it")
+	fs.Float64Var(&o.rate, "rate", o.rate, "maximum requests per second across all files, 0 means unlimited")
+	fs.IntVar(&o.burst, "burst", o.burst, "requests allowed to exceed -rate in a burst")
+	fs.StringVar(&o.include, "include", o.include, "comma separated globs of files to translate in directories (default *.docx)")
+	fs.StringVar(&o.exclude, "exclude", o.exclude, "comma separated globs of files and directories to skip")
+	fs.IntVar(&o.fileGo, "file-go", o.fileGo, "files translated at the same time in directories (default: -max-go)")
+	fs.DurationVar(&o.timeout, "timeout", o.timeout, "timeout of a single request")
+	fs.StringVar(&o.proxy, "proxy", o.proxy, "proxy URL for the translator")
+	fs.IntVar(&o.retries, "retries", o.retries, "retries per request, -1 uses the translator default")
+	fs.StringVar(&o.jobID, "job-id", o.jobID, "job ID sent to the translator with every request")
+	fs.StringVar(&o.labels, "labels", o.labels, "comma separated key=value labels sent to the translator with every request")
+	fs.StringVar(&o.reqModel, "request-model", o.reqModel, "model requested from the translator, replaces the provider model")
+	fs.Float64Var(&o.temp, "temperature", o.temp, "sampling temperature of every request (default: provider or domain default)")
+	fs.IntVar(&o.maxOutput, "request-max-tokens", o.maxOutput, "maximum tokens generated by every request")
+	fs.StringVar(&o.detect, "detect", o.detect, "comma separated candidate languages detected with -from all, off disables detection")
+	fs.Float64Var(&o.detectConf, "detect-confidence", o.detectConf, "minimum confidence of a detected language")
+	fs.BoolVar(&o.checkLang, "check-lang", o.checkLang, "fail chunks whose translation is not in the target language, requires a single -to")
+	fs.StringVar(&o.log, "log", o.log, "log destination: file, stderr or none")
+	fs.StringVar(&o.report, "report", o.report, "write the JSON report to this file")
+	fs.BoolVar(&o.quiet, "q", o.quiet, "do not print progress")
+	fs.BoolVar(&o.json, "json", o.json, "print results as JSON")
+	return fs
+}
+
+// parseArgs 解析参数，允许选项和输入交替出现，返回所有输入
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	inputs := make([]string, 0)
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return inputs, nil
+		}
+		inputs = append(inputs, args[0])
+		args = args[1:]
+	}
+}
+
+// input 命令行输入，目录按批量处理
+type input struct {
+	path string
+	dir  bool
+}
+
+// expandInputs 展开通配符并检查输入是否存在
+func expandInputs(args []string) ([]input, error) {
+	inputs := make([]input, 0, len(args))
+	for _, arg := range args {
+		paths := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("%s: no files matched", arg)
+			}
+			paths = matches
+		}
+
+		for _, p := range paths {
+			info, err := os.Stat(p)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, input{path: p, dir: info.IsDir()})
+		}
+	}
+	return inputs, nil
+}
+
+// job 合并配置文件和命令行选项，命令行中设置的选项优先，配置文件中没有的使用选项默认值
+func (o *options) job(fs *flag.FlagSet) (*config.Job, error) {
+	job := &config.Job{}
+	if o.config != "" {
+		var err error
+		if job, err = config.Load(o.config); err != nil {
+			return nil, err
+		}
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	// use 命令行设置了该选项，或配置文件中没有该值
+	use := func(name string, empty bool) bool {
+		return set[name] || empty
+	}
+
+	if use("o", job.Output == "") {
+		job.Output = o.output
+	}
+	if use("from", job.From == "") {
+		job.From = o.from
+	}
+	if use("to", len(job.To) == 0) {
+		job.To = config.SplitList(o.to)
+	}
+	if use("provider", job.Provider == "") {
+		job.Provider = o.provider
+	}
+	if use("model", job.Model == "") {
+		job.Model = o.model
+	}
+	if use("tokenizer", job.Tokenizer == "") {
+		job.Tokenizer = o.tokenizer
+	}
+	if use("max-go", job.MaxGo == 0) {
+		job.MaxGo = o.maxGo
+	}
+	if use("max-token", job.MaxToken == 0) {
+		job.MaxToken = o.maxToken
+	}
+	if use("expansion", job.Expansion == 0) {
+		job.Expansion = o.expansion
+	}
+	if use("latency", job.Latency == 0) {
+		job.Latency = o.latency
+	}
+	if use("strict", !job.Strict) {
+		job.Strict = o.strict
+	}
+	if use("segment", !job.Segment) {
+		job.Segment = o.segment
+	}
+	if use("prompt", job.Prompt == "") {
+		job.Prompt = o.prompt
+	}
+	if use("examples", job.Examples == "") {
+		job.Examples = o.examples
+	}
+	if use("summary", !job.Summary) {
+		job.Summary = o.summary
+	}
+	if use("context", job.ContextWindow == 0) {
+		job.ContextWindow = o.context
+	}
+	if use("domain", job.Domain == "") {
+		job.Domain = o.domain
+	}
+	if use("tone", job.Tone == "") {
+		job.Tone = o.tone
+	}
+	if use("formality", job.Formality == "") {
+		job.Formality = o.formality
+	}
+	if use("locale", len(job.Locale) == 0) {
+		job.Locale = config.SplitList(o.locale)
+	}
+	if use("audience", job.Audience == "") {
+		job.Audience = o.audience
+	}
+	if use("max-fail-ratio", job.MaxFailureRatio == 0) {
+		job.MaxFailureRatio = o.maxFail
+	}
+	if use("checkpoint", job.Checkpoint == "") {
+		job.Checkpoint = o.checkpoint
+	}
+	if use("rate", job.Rate == 0) {
+		job.Rate = o.rate
+	}
+	if use("burst", job.Burst == 0) {
+		job.Burst = o.burst
+	}
+	if use("include", len(job.Include) == 0) {
+		job.Include = config.SplitList(o.include)
+	}
+	if use("exclude", len(job.Exclude) == 0) {
+		job.Exclude = config.SplitList(o.exclude)
+	}
+	if use("file-go", job.FileGo == 0) {
+		job.FileGo = o.fileGo
+	}
+	if use("timeout", job.Timeout == 0) {
+		job.Timeout = o.timeout
+	}
+	if use("proxy", job.Proxy == "") {
+		job.Proxy = o.proxy
+	}
+	if set["retries"] && o.retries >= 0 {
+		if job.Retry == nil {
+			job.Retry = &config.Retry{}
+		}
+		retries := o.retries
+		job.Retry.MaxRetries = &retries
+	}
+	if use("job-id", job.JobID == "") {
+		job.JobID = o.jobID
+	}
+	if set["labels"] {
+		labels, err := parseLabels(o.labels)
+		if err != nil {
+			return nil, err
+		}
+		job.Labels = labels
+	}
+	if set["request-model"] || set["temperature"] || set["request-max-tokens"] {
+		if job.Request == nil {
+			job.Request = &config.Request{}
+		}
+		if set["request-model"] {
+			job.Request.Model = o.reqModel
+		}
+		if set["temperature"] {
+			temp := o.temp
+			job.Request.Temperature = &temp
+		}
+		if set["request-max-tokens"] {
+			job.Request.MaxTokens = o.maxOutput
+		}
+	}
+	if use("detect", len(job.Detect) == 0) {
+		job.Detect = config.SplitList(o.detect)
+	}
+	if use("detect-confidence", job.DetectMinConf == 0) {
+		job.DetectMinConf = o.detectConf
+	}
+	if use("check-lang", !job.CheckLang) {
+		job.CheckLang = o.checkLang
+	}
+
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// parseLabels 解析逗号分隔的 key=value 标签
+func parseLabels(s string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, item := range config.SplitList(s) {
+		k, v, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("-labels: expected key=value, got %q", item)
+		}
+		labels[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return labels, nil
+}
+
+// processorOpts 根据任务配置生成处理器选项，并设置日志输出
+func (o *options) processorOpts(job *config.Job, stderr io.Writer) ([]eden.Opt, error) {
+	opts, err := job.Options()
+	if err != nil {
+		return nil, err
+	}
+	logOpts, err := o.logOpts(stderr)
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, logOpts...), nil
+}
+
+// logOpts 日志输出选项，file 使用处理器默认的日志文件
+func (o *options) logOpts(stderr io.Writer) ([]eden.Opt, error) {
+	switch o.log {
+	case "file":
+		return nil, nil
+	case "stderr":
+		return []eden.Opt{eden.WithLogWriter(stderr)}, nil
+	case "none":
+		return []eden.Opt{eden.WithLogWriter(io.Discard)}, nil
+	default:
+		return nil, fmt.Errorf("-log: unknown destination %q", o.log)
+	}
+}
@@ -0,0 +1,419 @@
+// eden 命令行工具，翻译 DOCX 文档
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gou-jjjj/eden"
+	"github.com/gou-jjjj/eden/config"
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/translate"
+)
+
+// 退出码
+const (
+	exitOK      = 0 // 全部成功
+	exitFailed  = 1 // 全部失败或运行出错
+	exitUsage   = 2 // 参数错误
+	exitPartial = 3 // 部分文件或分块失败
+)
+
+const usageText = `Usage: eden <command> [flags] [inputs...]
+
+Commands:
+  translate   translate DOCX files, globs or directories
+  estimate    estimate requests, tokens, cost and duration without translating
+  extract     print the chunks that would be sent to the translator
+  languages   list supported languages
+  providers   list available translators
+  serve       run the HTTP translation service
+  watch       translate files dropped into an inbox directory
+
+Run 'eden <command> -h' for the flags of a command.
+
+Exit codes: 0 success, 1 failure, 2 usage error, 3 partial failure.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run 执行命令，返回退出码
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usageText)
+		return exitUsage
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "translate":
+		return runTranslate(cmd, args, stdout, stderr)
+	case "estimate":
+		return runTranslate(cmd, args, stdout, stderr)
+	case "extract":
+		return runExtract(args, stdout, stderr)
+	case "languages":
+		return runLanguages(stdout)
+	case "providers":
+		return runProviders(stdout)
+	case "serve":
+		return runServe(args, stderr)
+	case "watch":
+		return runWatch(args, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usageText)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "eden: unknown command %q\n\n%s", cmd, usageText)
+		return exitUsage
+	}
+}
+
+// status 单个输入的处理状态
+type status int
+
+const (
+	statusOK status = iota
+	statusPartial
+	statusFailed
+)
+
+func (s status) String() string {
+	switch s {
+	case statusOK:
+		return "OK"
+	case statusPartial:
+		return "PARTIAL"
+	default:
+		return "FAILED"
+	}
+}
+
+// result 单个输入的处理结果
+type result struct {
+	Input  string            `json:"input"`
+	Status string            `json:"status"`
+	Report *eden.Report      `json:"report,omitempty"`
+	Batch  *eden.BatchReport `json:"batch,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	status status
+}
+
+// setStatus 根据错误和报告设置状态
+func (r *result) setStatus(err error) {
+	switch {
+	case r.Batch != nil && r.Batch.Succeeded == 0 && r.Batch.Failed > 0:
+		r.status = statusFailed
+	case r.Batch != nil && (r.Batch.Failed > 0 || r.Batch.FailedChunks > 0):
+		r.status = statusPartial
+	case r.Batch == nil && err != nil:
+		r.status = statusFailed
+	case r.Report != nil && r.Report.Failed > 0:
+		r.status = statusPartial
+	default:
+		r.status = statusOK
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	r.Status = strings.ToLower(r.status.String())
+}
+
+// exitCode 汇总所有结果的退出码
+func exitCode(results []result) int {
+	failed, partial := 0, 0
+	for _, r := range results {
+		switch r.status {
+		case statusFailed:
+			failed++
+		case statusPartial:
+			partial++
+		}
+	}
+
+	switch {
+	case len(results) > 0 && failed == len(results):
+		return exitFailed
+	case failed > 0 || partial > 0:
+		return exitPartial
+	default:
+		return exitOK
+	}
+}
+
+// runTranslate 执行 translate 和 estimate 命令，estimate 只试运行
+func runTranslate(cmd string, args []string, stdout, stderr io.Writer) int {
+	dryRun := cmd == "estimate"
+	o := defaultOptions(cmd)
+	fs := newFlagSet(cmd, o, stderr)
+	inputs, job, code, ok := parseCommand(fs, o, args, stderr)
+	if !ok {
+		return code
+	}
+
+	if !dryRun && job.Provider == "" {
+		fmt.Fprintf(stderr, "eden %s: -provider is required, see 'eden providers'\n", cmd)
+		return exitUsage
+	}
+	opts, err := o.processorOpts(job, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "eden %s: %v\n", cmd, err)
+		return exitUsage
+	}
+	if dryRun {
+		opts = append(opts, eden.WithDryRun())
+	}
+
+	prog := newProgress(stderr, o.quiet || dryRun)
+	opts = append(opts, eden.WithEventHandler(prog.handle))
+
+	results := make([]result, 0, len(inputs))
+	for _, in := range inputs {
+		res := result{Input: in.path}
+		if in.dir {
+			res.Batch, err = eden.NewBatch(in.path, job.Output, job.BatchOptions(opts)...).Run()
+			if err == nil && len(res.Batch.Files) == 0 {
+				err = fmt.Errorf("no files matched in %s", in.path)
+				res.Batch = nil
+			}
+		} else {
+			pOpts := append(append([]eden.Opt{}, opts...), eden.WithInput(in.path), eden.WithOutput(job.Output))
+			res.Report, err = eden.NewDocxProcessor(pOpts...).Process()
+		}
+		res.setStatus(err)
+		results = append(results, res)
+	}
+	prog.finish()
+
+	if o.report != "" {
+		if err := writeJSON(o.report, results); err != nil {
+			fmt.Fprintf(stderr, "eden %s: write report: %v\n", cmd, err)
+			return exitFailed
+		}
+	}
+
+	switch {
+	case o.json:
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(results)
+	case dryRun:
+		printEstimates(stdout, results)
+	default:
+		printResults(stdout, results)
+	}
+	return exitCode(results)
+}
+
+// runExtract 执行 extract 命令，输出每个文件的分块
+func runExtract(args []string, stdout, stderr io.Writer) int {
+	o := defaultOptions("extract")
+	fs := newFlagSet("extract", o, stderr)
+	inputs, job, code, ok := parseCommand(fs, o, args, stderr)
+	if !ok {
+		return code
+	}
+
+	opts, err := o.processorOpts(job, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "eden extract: %v\n", err)
+		return exitUsage
+	}
+
+	files := make([]string, 0)
+	for _, in := range inputs {
+		if !in.dir {
+			files = append(files, in.path)
+			continue
+		}
+		rels, err := eden.NewBatch(in.path, "", job.BatchOptions(nil)...).Files()
+		if err != nil {
+			fmt.Fprintf(stderr, "eden extract: %v\n", err)
+			return exitFailed
+		}
+		for _, rel := range rels {
+			files = append(files, filepath.Join(in.path, rel))
+		}
+	}
+
+	type extracted struct {
+		Input  string                `json:"input"`
+		Chunks []translate.Paragraph `json:"chunks"`
+		Error  string                `json:"error,omitempty"`
+	}
+	all := make([]extracted, 0, len(files))
+	results := make([]result, 0, len(files))
+	for _, file := range files {
+		pOpts := append(append([]eden.Opt{}, opts...), eden.WithInput(file))
+		chunks, err := eden.NewDocxProcessor(pOpts...).Extract()
+		ex := extracted{Input: file, Chunks: chunks}
+		res := result{Input: file}
+		res.setStatus(err)
+		if err != nil {
+			ex.Error = err.Error()
+			fmt.Fprintf(stderr, "eden extract: %s: %v\n", file, err)
+		}
+		all = append(all, ex)
+		results = append(results, res)
+	}
+
+	if o.json {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(all)
+		return exitCode(results)
+	}
+	for _, ex := range all {
+		if ex.Error != "" {
+			continue
+		}
+		fmt.Fprintf(stdout, "# %s\n", ex.Input)
+		for i, chunk := range ex.Chunks {
+			fmt.Fprintf(stdout, "\n## chunk %d (%d segments)\n%s\n", i, len(chunk), strings.Join(chunk, translate.Seq))
+		}
+		fmt.Fprintln(stdout)
+	}
+	return exitCode(results)
+}
+
+// runLanguages 列出支持的语言
+func runLanguages(stdout io.Writer) int {
+	codes := make([]string, 0, len(lang.LangCodes))
+	for code := range lang.LangCodes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CODE\tLANGUAGE\tNAME")
+	for _, code := range codes {
+		l := lang.LangCodes[code]
+		fmt.Fprintf(w, "%s\t%s\t%s\n", code, l, lang.LangNames[l])
+	}
+	_ = w.Flush()
+	return exitOK
+}
+
+// runProviders 列出可用的翻译器
+func runProviders(stdout io.Writer) int {
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tMODEL\tURL")
+	for _, name := range translate.Providers() {
+		if name == translate.Mock {
+			fmt.Fprintf(w, "%s\t-\treturns the source text, for testing\n", name)
+			continue
+		}
+		m := translate.OpenaiModelList[name]
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, m.Model, m.Url)
+	}
+	_ = w.Flush()
+	return exitOK
+}
+
+// parseCommand 解析参数和配置文件并展开输入，失败时返回退出码
+func parseCommand(fs *flag.FlagSet, o *options, args []string, stderr io.Writer) ([]input, *config.Job, int, bool) {
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, nil, exitOK, false
+		}
+		return nil, nil, exitUsage, false
+	}
+	job, err := o.job(fs)
+	if err != nil {
+		fmt.Fprintf(stderr, "eden %s: %v\n", fs.Name(), err)
+		return nil, nil, exitUsage, false
+	}
+	if len(args) == 0 {
+		fmt.Fprintf(stderr, "eden %s: no input files\n", fs.Name())
+		fs.Usage()
+		return nil, nil, exitUsage, false
+	}
+
+	inputs, err := expandInputs(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "eden %s: %v\n", fs.Name(), err)
+		return nil, nil, exitUsage, false
+	}
+	return inputs, job, exitOK, true
+}
+
+// printResults 输出翻译结果
+func printResults(stdout io.Writer, results []result) {
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	for _, r := range results {
+		switch {
+		case r.Batch != nil:
+			b := r.Batch
+			fmt.Fprintf(w, "%s\t%s\t-> %s\tfiles %d ok, %d failed\tchunks %d translated, %d skipped, %d failed\t$%.4f\n",
+				r.status, r.Input, b.Output, b.Succeeded, b.Failed, b.Translated, b.Skipped, b.FailedChunks, b.Cost)
+			for _, f := range b.Files {
+				if f.Err != nil {
+					fmt.Fprintf(w, "\t  %s\t%s\n", f.Path, f.Error)
+				}
+			}
+		case r.Report != nil && r.Error == "":
+			rp := r.Report
+			fmt.Fprintf(w, "%s\t%s\t-> %s\tchunks %d translated, %d skipped, %d failed\t$%.4f\n",
+				r.status, r.Input, rp.Output, rp.Translated, rp.Skipped, rp.Failed, rp.Cost)
+		default:
+			fmt.Fprintf(w, "%s\t%s\t%s\n", r.status, r.Input, r.Error)
+		}
+	}
+	_ = w.Flush()
+}
+
+// printEstimates 输出预估结果和合计
+func printEstimates(stdout io.Writer, results []result) {
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "INPUT\tCHUNKS\tREQUESTS\tPROMPT\tCOMPLETION\tCOST\tDURATION\t")
+
+	total := eden.Estimate{}
+	line := func(name string, est *eden.Estimate) {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t$%.4f\t%s\t\n", name, est.Chunks, est.Requests,
+			est.PromptTokens, est.CompletionTokens, est.Cost, est.Duration.Round(time.Second))
+		total.Chunks += est.Chunks
+		total.Requests += est.Requests
+		total.PromptTokens += est.PromptTokens
+		total.CompletionTokens += est.CompletionTokens
+		total.Cost += est.Cost
+		total.Duration += est.Duration
+	}
+	for _, r := range results {
+		switch {
+		case r.Batch != nil:
+			for _, f := range r.Batch.Files {
+				if f.Report != nil && f.Report.Estimate != nil {
+					line(filepath.Join(r.Input, f.Path), f.Report.Estimate)
+				}
+			}
+		case r.Report != nil && r.Report.Estimate != nil:
+			line(r.Input, r.Report.Estimate)
+		default:
+			fmt.Fprintf(w, "%s\t%s\t\t\t\t\t\t\n", r.Input, r.Error)
+		}
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\t%d\t%d\t%d\t$%.4f\t%s\t\n", total.Chunks, total.Requests,
+		total.PromptTokens, total.CompletionTokens, total.Cost, total.Duration.Round(time.Second))
+	_ = w.Flush()
+}
+
+// writeJSON 把 v 以 JSON 格式写入文件
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gou-jjjj/eden"
+)
+
+const example = "../../file_examples/Docx4j_GettingStarted.docx"
+
+func runCmd(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	code := run(args, stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	cases := [][]string{
+		{},
+		{"nope"},
+		{"translate", "-provider", "mock"},
+		{"translate", example},
+		{"translate", "-provider", "nope", example},
+		{"translate", "-provider", "mock", "-to", "xx", example},
+		{"translate", "-provider", "mock", "-labels", "team", example},
+		{"translate", "-provider", "mock", "-to", "zh,ja", "-check-lang", example},
+		{"translate", "-provider", "mock", "missing.docx"},
+		{"translate", "-bad-flag", example},
+		{"serve", "-to", "xx"},
+		{"serve", example},
+		{"watch"},
+		{"watch", "-provider", "mock", "missing-inbox"},
+		{"watch", "-provider", "mock", "-to", "xx", "."},
+	}
+	for _, args := range cases {
+		if code, _, _ := runCmd(t, args...); code != exitUsage {
+			t.Errorf("run(%v) = %d, want %d", args, code, exitUsage)
+		}
+	}
+
+	if code, out, _ := runCmd(t, "help"); code != exitOK || !strings.Contains(out, "translate") {
+		t.Errorf("Unexpected help output %d %q", code, out)
+	}
+}
+
+func TestRun_Translate(t *testing.T) {
+	out := t.TempDir()
+	in := t.TempDir()
+	src, err := os.ReadFile(example)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(in, "a.docx"), src, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reportPath := filepath.Join(out, "report.json")
+	code, stdout, stderr := runCmd(t, "translate", example, "-provider", "mock", "-to", "zh,ja",
+		"-o", out, "-log", "none", "-max-token", "200", "-report", reportPath, in)
+	if code != exitOK {
+		t.Fatalf("Exit code %d, stderr %q", code, stderr)
+	}
+	if !strings.Contains(stdout, "OK") {
+		t.Errorf("Unexpected output %q", stdout)
+	}
+
+	for _, name := range []string{"Docx4j_GettingStarted_中文.docx", "Docx4j_GettingStarted_日文.docx", "a_中文.docx", "a_日文.docx"} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Errorf("Expected output %s: %v", name, err)
+		}
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []result
+	if err := json.Unmarshal(data, &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Report == nil || results[1].Batch == nil {
+		t.Errorf("Unexpected report %s", data)
+	}
+}
+
+func TestRun_Config(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "job.yaml")
+	if err := os.WriteFile(cfg, []byte("provider: mock\nto: [zh]\nmax_token: 200\nglossary:\n  Docx4j: Docx4j\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 命令行选项优先于配置文件
+	code, _, stderr := runCmd(t, "translate", "-config", cfg, "-log", "none", "-o", dir, "-to", "ja", example)
+	if code != exitOK {
+		t.Fatalf("Exit code %d, stderr %q", code, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Docx4j_GettingStarted_日文.docx")); err != nil {
+		t.Error(err)
+	}
+
+	if err := os.WriteFile(cfg, []byte("provider: mock\nmax_go: -1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, stderr := runCmd(t, "translate", "-config", cfg, example); code != exitUsage || !strings.Contains(stderr, "line 2: max_go") {
+		t.Errorf("Expected usage error pointing to max_go, got %d %q", code, stderr)
+	}
+}
+
+func TestOptions_Job(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "job.yaml")
+	data := "provider: mock\nto: [zh]\nlabels:\n  team: docs\nrequest:\n  model: big\n  max_tokens: 100\ndetect: [en, fr]\n"
+	if err := os.WriteFile(cfg, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := defaultOptions("translate")
+	fs := newFlagSet("translate", o, io.Discard)
+	args := []string{"-config", cfg, "-job-id", "j1", "-labels", "env=prod", "-temperature", "0.2", "-detect", "off", "-check-lang"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	job, err := o.job(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 请求选项按字段合并，标签和候选语言由命令行整体替换
+	if job.JobID != "j1" || job.Labels["env"] != "prod" || job.Labels["team"] != "" || !job.CheckLang {
+		t.Errorf("Unexpected job %+v", job)
+	}
+	r := job.Request
+	if r.Model != "big" || r.MaxTokens != 100 || r.Temperature == nil || *r.Temperature != 0.2 {
+		t.Errorf("Unexpected request options %+v", r)
+	}
+	if len(job.Detect) != 1 || job.Detect[0] != "off" {
+		t.Errorf("Unexpected detect %v", job.Detect)
+	}
+	if _, err := job.Options(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRun_EstimateAndExtract(t *testing.T) {
+	code, stdout, stderr := runCmd(t, "estimate", "-model", "gpt-4o", "-to", "zh", "-max-token", "200", example)
+	if code != exitOK || !strings.Contains(stdout, "TOTAL") {
+		t.Fatalf("Unexpected estimate %d %q %q", code, stdout, stderr)
+	}
+
+	code, stdout, _ = runCmd(t, "extract", "-json", "-to", "zh", "-max-token", "200", example)
+	if code != exitOK {
+		t.Fatalf("Unexpected extract exit code %d", code)
+	}
+	var extracted []struct {
+		Chunks [][]string `json:"chunks"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &extracted); err != nil || len(extracted) != 1 || len(extracted[0].Chunks) == 0 {
+		t.Errorf("Unexpected extract output %q: %v", stdout, err)
+	}
+
+	for _, cmd := range []string{"languages", "providers"} {
+		if code, stdout, _ := runCmd(t, cmd); code != exitOK || stdout == "" {
+			t.Errorf("Unexpected %s output %d %q", cmd, code, stdout)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	ok := result{status: statusOK}
+	partial := result{status: statusPartial}
+	failed := result{status: statusFailed}
+
+	cases := []struct {
+		results []result
+		want    int
+	}{
+		{[]result{ok, ok}, exitOK},
+		{[]result{ok, partial}, exitPartial},
+		{[]result{ok, failed}, exitPartial},
+		{[]result{failed, failed}, exitFailed},
+	}
+	for _, c := range cases {
+		if got := exitCode(c.results); got != c.want {
+			t.Errorf("exitCode(%v) = %d, want %d", c.results, got, c.want)
+		}
+	}
+
+	r := result{Report: &eden.Report{Failed: 1, Translated: 3}}
+	r.setStatus(nil)
+	if r.status != statusPartial {
+		t.Errorf("Expected partial status for failed chunks, got %s", r.status)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gou-jjjj/eden"
+)
+
+// progress 汇总所有文件的进度并输出到终端
+type progress struct {
+	mu     sync.Mutex
+	w      io.Writer
+	quiet  bool
+	tty    bool
+	start  time.Time
+	total  int
+	done   int
+	failed int
+	files  int
+	drawn  bool
+}
+
+// newProgress 创建进度输出，w 是终端时原地刷新，否则每个文件完成时输出一行
+func newProgress(w io.Writer, quiet bool) *progress {
+	tty := false
+	if f, ok := w.(*os.File); ok {
+		if info, err := f.Stat(); err == nil {
+			tty = info.Mode()&os.ModeCharDevice != 0
+		}
+	}
+	return &progress{w: w, quiet: quiet, tty: tty, start: time.Now()}
+}
+
+// handle 处理器的事件回调，多个处理器可以同时调用
+func (p *progress) handle(e eden.Event) {
+	if p.quiet {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch e.Type {
+	case eden.EventExtracted:
+		p.total += e.Total
+	case eden.EventChunkSucceeded, eden.EventChunkSkipped:
+		p.done++
+	case eden.EventChunkFailed:
+		p.done++
+		p.failed++
+	case eden.EventDone:
+		p.files++
+		if !p.tty {
+			fmt.Fprintf(p.w, "%s\n", p.line())
+			return
+		}
+	default:
+		return
+	}
+
+	if p.tty {
+		fmt.Fprintf(p.w, "\r\033[K%s", p.line())
+		p.drawn = true
+	}
+}
+
+// line 当前进度
+func (p *progress) line() string {
+	elapsed := time.Since(p.start)
+	s := fmt.Sprintf("files %d, chunks %d/%d, failed %d, elapsed %s",
+		p.files, p.done, p.total, p.failed, elapsed.Round(time.Second))
+	if p.done > 0 && p.done < p.total {
+		eta := elapsed / time.Duration(p.done) * time.Duration(p.total-p.done)
+		s += fmt.Sprintf(", eta %s", eta.Round(time.Second))
+	}
+	return s
+}
+
+// finish 结束进度输出
+func (p *progress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.drawn {
+		fmt.Fprintln(p.w)
+	}
+}
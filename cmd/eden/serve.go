@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gou-jjjj/eden/config"
+	"github.com/gou-jjjj/eden/server"
+)
+
+// runServe 执行 serve 命令，启动翻译服务直到收到中断信号
+func runServe(args []string, stderr io.Writer) int {
+	o := defaultOptions("serve")
+	fs := newFlagSet("serve", o, stderr)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	workers := fs.Int("workers", 1, "documents translated at the same time")
+	queue := fs.Int("queue", server.DefaultQueueSize, "maximum queued jobs, uploads are rejected when full")
+	retention := fs.Duration("retention", server.DefaultRetention, "how long finished jobs and their outputs are kept")
+	history := fs.Int("event-history", server.DefaultEventHistory, "event stream messages kept per job, older ones are dropped")
+	maxUpload := fs.Int64("max-upload", server.DefaultMaxUpload, "maximum upload size in bytes")
+	secret := fs.String("webhook-secret", os.Getenv("EDEN_WEBHOOK_SECRET"), "key used to sign webhook payloads (default $EDEN_WEBHOOK_SECRET)")
+	baseURL := fs.String("base-url", "", "absolute external URL of the service, required for webhooks and used for their download links")
+	allow := fs.String("webhook-allow", "", "comma-separated hosts webhooks may be sent to, including private addresses (default any public host)")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, "Usage: eden serve [flags]\n\nTranslation flags are the defaults of uploaded jobs.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "eden serve: unexpected arguments %v\n", fs.Args())
+		return exitUsage
+	}
+	job, err := o.job(fs)
+	if err != nil {
+		fmt.Fprintf(stderr, "eden serve: %v\n", err)
+		return exitUsage
+	}
+
+	s := server.New(
+		server.WithDefaults(job),
+		server.WithWorkers(*workers),
+		server.WithMaxGo(job.MaxGo),
+		server.WithQueueSize(*queue),
+		server.WithRetention(*retention),
+		server.WithEventHistory(*history),
+		server.WithMaxUpload(*maxUpload),
+		server.WithWebhookSecret(*secret),
+		server.WithWebhookAllowlist(config.SplitList(*allow)...),
+		server.WithBaseURL(*baseURL))
+	defer s.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: *addr, Handler: s}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	fmt.Fprintf(stderr, "eden serve: listening on %s\n", *addr)
+
+	select {
+	case err := <-errc:
+		fmt.Fprintf(stderr, "eden serve: %v\n", err)
+		return exitFailed
+	case <-ctx.Done():
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil {
+		fmt.Fprintf(stderr, "eden serve: %v\n", err)
+		return exitFailed
+	}
+	return exitOK
+}
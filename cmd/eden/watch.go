@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gou-jjjj/eden/logger"
+	"github.com/gou-jjjj/eden/watch"
+)
+
+// runWatch 执行 watch 命令，监视收件目录直到收到中断信号
+func runWatch(args []string, stdout, stderr io.Writer) int {
+	o := defaultOptions("watch")
+	fs := newFlagSet("watch", o, stderr)
+	done := fs.String("done", "", "directory for translated originals (default <inbox>/done)")
+	failed := fs.String("failed", "", "directory for originals that failed (default <inbox>/failed)")
+	interval := fs.Duration("interval", watch.DefaultInterval, "how often the inbox is scanned")
+	settle := fs.Duration("settle", watch.DefaultSettle, "how long a file must stay unchanged before it is translated")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, "Usage: eden watch [flags] <inbox>\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	inputs, err := parseArgs(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if len(inputs) != 1 {
+		fmt.Fprintln(stderr, "eden watch: exactly one inbox directory is required")
+		fs.Usage()
+		return exitUsage
+	}
+	job, err := o.job(fs)
+	if err != nil {
+		fmt.Fprintf(stderr, "eden watch: %v\n", err)
+		return exitUsage
+	}
+	if job.Provider == "" {
+		fmt.Fprintln(stderr, "eden watch: -provider is required, see 'eden providers'")
+		return exitUsage
+	}
+	opts, err := o.logOpts(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "eden watch: %v\n", err)
+		return exitUsage
+	}
+
+	wOpts := []watch.Opt{
+		watch.WithInterval(*interval),
+		watch.WithSettle(*settle),
+		watch.WithDocxOptions(opts...),
+		watch.WithLogger(logger.NewWriterLogger(stderr, false)),
+		watch.WithResultHandler(func(r watch.Result) {
+			res := result{Input: r.Input, Report: r.Report}
+			res.setStatus(r.Err)
+			printResults(stdout, []result{res})
+		}),
+	}
+	if *done != "" {
+		wOpts = append(wOpts, watch.WithDoneDir(*done))
+	}
+	if *failed != "" {
+		wOpts = append(wOpts, watch.WithFailedDir(*failed))
+	}
+
+	w, err := watch.New(inputs[0], job.Output, job, wOpts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "eden watch: %v\n", err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(stderr, "eden watch: watching %s, translations are written to %s\n", inputs[0], job.Output)
+	if err := w.Run(ctx); err != nil {
+		fmt.Fprintf(stderr, "eden watch: %v\n", err)
+		return exitFailed
+	}
+	return exitOK
+}
@@ -0,0 +1,304 @@
+// Package config 声明式任务配置，支持 YAML、JSON 和 TOML 格式，映射为处理器选项和翻译器
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Job 任务配置，可以保存为可复用的任务模板
+type Job struct {
+	Provider        string            `yaml:"provider" toml:"provider"`                   // 翻译器，见 translate.Providers
+	Fallbacks       Strings           `yaml:"fallbacks" toml:"fallbacks"`                 // 主翻译器失败后依次尝试的翻译器
+	Model           string            `yaml:"model" toml:"model"`                         // 模型名，用于分词器、上下文限制和价格，默认使用翻译器的模型
+	Tokenizer       string            `yaml:"tokenizer" toml:"tokenizer"`                 // 分词器：rune、estimate 或 tiktoken
+	From            string            `yaml:"from" toml:"from"`                           // 源语言代码，默认 all
+	To              Strings           `yaml:"to" toml:"to"`                               // 目标语言代码，可以有多个
+	Output          string            `yaml:"output" toml:"output"`                       // 输出目录
+	MaxGo           int               `yaml:"max_go" toml:"max_go"`                       // 最大并发请求数
+	MaxToken        int               `yaml:"max_token" toml:"max_token"`                 // 单个分块最多包含的原文 token 数
+	Expansion       float64           `yaml:"expansion" toml:"expansion"`                 // 译文相对原文的 token 膨胀系数
+	Latency         time.Duration     `yaml:"latency" toml:"latency"`                     // 估算耗时使用的单次请求耗时
+	Strict          bool              `yaml:"strict" toml:"strict"`                       // 任意分块失败时不保存文件
+	MaxFailureRatio float64           `yaml:"max_failure_ratio" toml:"max_failure_ratio"` // 失败分块占比超过该值时不保存文件
+	Checkpoint      string            `yaml:"checkpoint" toml:"checkpoint"`               // 检查点目录
+	Rate            float64           `yaml:"rate" toml:"rate"`                           // 每秒最多请求数，0 表示不限制
+	Burst           int               `yaml:"burst" toml:"burst"`                         // 允许的突发请求数
+	Timeout         time.Duration     `yaml:"timeout" toml:"timeout"`                     // 单次请求超时时间
+	Proxy           string            `yaml:"proxy" toml:"proxy"`                         // 代理地址
+	Retry           *Retry            `yaml:"retry" toml:"retry"`                         // 重试配置，不设置时使用翻译器默认配置
+	Breaker         *Breaker          `yaml:"breaker" toml:"breaker"`                     // 熔断器配置，不设置时使用默认配置
+	Glossary        map[string]string `yaml:"glossary" toml:"glossary"`                   // 术语表
+	Segment         bool              `yaml:"segment" toml:"segment"`                     // 按句子拆分片段，跨文本块的句子整体翻译
+	Summary         bool              `yaml:"summary" toml:"summary"`                     // 翻译前生成文档摘要，随每个请求发送
+	ContextWindow   int               `yaml:"context_window" toml:"context_window"`       // 每个请求附带之前的片段数
+	Prompt          string            `yaml:"prompt" toml:"prompt"`                       // 翻译提示词模板文件
+	PromptSingle    string            `yaml:"prompt_single" toml:"prompt_single"`         // 单段请求的提示词模板文件，默认使用 prompt
+	Examples        string            `yaml:"examples" toml:"examples"`                   // 少样本示例文件，替换内置示例中相同语言对的示例
+	Domain          string            `yaml:"domain" toml:"domain"`                       // 文档领域，如 legal、medical、technical、marketing
+	Tone            string            `yaml:"tone" toml:"tone"`                           // 译文语气
+	Formality       string            `yaml:"formality" toml:"formality"`                 // 正式程度：formal 或 informal
+	Locale          Strings           `yaml:"locale" toml:"locale"`                       // 目标语言的地区变体，如 zh-TW、en-GB，按语言匹配目标语言
+	Audience        string            `yaml:"audience" toml:"audience"`                   // 译文的目标读者
+	Include         Strings           `yaml:"include" toml:"include"`                     // 目录中需要翻译的文件
+	Exclude         Strings           `yaml:"exclude" toml:"exclude"`                     // 目录中忽略的文件和目录
+	FileGo          int               `yaml:"file_go" toml:"file_go"`                     // 目录中同时处理的文件数
+	JobID           string            `yaml:"job_id" toml:"job_id"`                       // 任务 ID，随每个请求发送给翻译器
+	Labels          map[string]string `yaml:"labels" toml:"labels"`                       // 自定义标签，随每个请求发送给翻译器
+	Request         *Request          `yaml:"request" toml:"request"`                     // 每个请求的选项，如替换模型、采样温度
+	Detect          Strings           `yaml:"detect" toml:"detect"`                       // 源语言为 all 时检测的候选语言，off 表示不检测
+	DetectMinConf   float64           `yaml:"detect_confidence" toml:"detect_confidence"` // 语言检测的最低置信度
+	CheckLang       bool              `yaml:"check_lang" toml:"check_lang"`               // 检查译文是否为目标语言，只支持一个目标语言
+
+	lines map[string]int // 键在配置文件中的行号
+}
+
+// Retry 重试配置，未设置的字段使用 translate.DefaultRetryConfig
+type Retry struct {
+	MaxRetries    *int          `yaml:"max_retries" toml:"max_retries"`
+	BaseDelay     time.Duration `yaml:"base_delay" toml:"base_delay"`
+	MaxDelay      time.Duration `yaml:"max_delay" toml:"max_delay"`
+	BackoffFactor float64       `yaml:"backoff_factor" toml:"backoff_factor"`
+	Jitter        *float64      `yaml:"jitter" toml:"jitter"`
+}
+
+// Request 请求选项，对应 translate.ReqOptions
+type Request struct {
+	Model       string   `yaml:"model" toml:"model"`
+	Temperature *float64 `yaml:"temperature" toml:"temperature"`
+	MaxTokens   int      `yaml:"max_tokens" toml:"max_tokens"`
+}
+
+// Breaker 熔断器配置，未设置的字段使用 translate.DefaultBreakerConfig
+type Breaker struct {
+	FailureThreshold int           `yaml:"failure_threshold" toml:"failure_threshold"`
+	OpenTimeout      time.Duration `yaml:"open_timeout" toml:"open_timeout"`
+	HalfOpenProbes   int           `yaml:"half_open_probes" toml:"half_open_probes"`
+}
+
+// Strings 字符串列表，配置中也可以写成单个字符串或逗号分隔的字符串
+type Strings []string
+
+// UnmarshalYAML 支持列表和逗号分隔的字符串
+func (s *Strings) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*s = SplitList(node.Value)
+		return nil
+	case yaml.SequenceNode:
+		list := make([]string, 0, len(node.Content))
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+		*s = list
+		return nil
+	default:
+		return fmt.Errorf("line %d: expected a string or a list of strings", node.Line)
+	}
+}
+
+// UnmarshalTOML 支持数组和逗号分隔的字符串
+func (s *Strings) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*s = SplitList(v)
+		return nil
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("expected a string or a list of strings, got %v", item)
+			}
+			list = append(list, str)
+		}
+		*s = list
+		return nil
+	default:
+		return fmt.Errorf("expected a string or a list of strings, got %v", v)
+	}
+}
+
+// SplitList 拆分逗号分隔的列表，忽略空项
+func SplitList(s string) []string {
+	res := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// Error 配置校验错误，指向出错的键
+type Error struct {
+	Key  string // 键的路径，如 retry.max_retries、to[1]
+	Line int    // 配置文件中的行号，0 表示不是从文件加载的
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", e.Line, e.Key, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Key, e.Msg)
+}
+
+// Load 加载配置文件，.toml 文件按 TOML 解析，.json 和 .yaml 文件都按 YAML 解析
+func Load(file string) (*Job, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	parse := Parse
+	if strings.EqualFold(path.Ext(file), ".toml") {
+		parse = ParseTOML
+	}
+	job, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return job, nil
+}
+
+// Parse 解析并校验配置，未知的键会报错
+func Parse(data []byte) (*Job, error) {
+	job := &Job{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(job); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	job.lines = map[string]int{}
+	collectLines(&root, "", job.lines)
+
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ParseTOML 解析并校验 TOML 配置，未知的键会报错
+func ParseTOML(data []byte) (*Job, error) {
+	job := &Job{}
+	md, err := toml.Decode(string(data), job)
+	if err != nil {
+		return nil, err
+	}
+	job.lines = tomlLines(string(data))
+
+	errs := make([]error, 0)
+	for _, key := range md.Undecoded() {
+		errs = append(errs, job.errorf(key.String(), "unknown key"))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// tomlLines 记录每个键的行号，数组元素使用数组的行号
+func tomlLines(data string) map[string]int {
+	lines := map[string]int{}
+	table := ""
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "["):
+			name, _, _ := strings.Cut(strings.Trim(line, "[ "), "]")
+			table = tomlKey(name)
+			lines[table] = i + 1
+			continue
+		}
+
+		key, _, ok := strings.Cut(line, "=")
+		if !ok || strings.ContainsAny(key, `[{,`) {
+			continue
+		}
+		if key = tomlKey(key); table != "" {
+			key = table + "." + key
+		}
+		if _, ok := lines[key]; !ok {
+			lines[key] = i + 1
+		}
+	}
+	return lines
+}
+
+// tomlKey 去掉键中的空白和引号
+func tomlKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// collectLines 记录每个键的行号
+func collectLines(node *yaml.Node, prefix string, lines map[string]int) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			collectLines(n, prefix, lines)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			lines[key] = node.Content[i].Line
+			collectLines(node.Content[i+1], key, lines)
+		}
+	case yaml.SequenceNode:
+		for i, n := range node.Content {
+			key := prefix + "[" + strconv.Itoa(i) + "]"
+			lines[key] = n.Line
+			collectLines(n, key, lines)
+		}
+	}
+}
+
+// errorf 创建指向 key 的校验错误
+func (j *Job) errorf(key, format string, args ...any) error {
+	return &Error{Key: key, Line: j.line(key), Msg: fmt.Sprintf(format, args...)}
+}
+
+// line 键的行号，列表元素不存在时使用列表的行号
+func (j *Job) line(key string) int {
+	if l, ok := j.lines[key]; ok {
+		return l
+	}
+	if i := strings.IndexByte(key, '['); i > 0 {
+		return j.lines[key[:i]]
+	}
+	return 0
+}
+
+// validPattern 检查通配符是否合法
+func validPattern(pattern string) bool {
+	_, err := path.Match(pattern, "")
+	return err == nil
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gou-jjjj/eden/translate"
+)
+
+const jobYAML = `
+provider: zhipu
+fallbacks: [alibaba, mock]
+from: en
+to: zh, ja
+max_go: 8
+timeout: 30s
+retry:
+  max_retries: 0
+  max_delay: 10s
+breaker:
+  failure_threshold: 3
+segment: true
+glossary:
+  eden: 伊甸
+exclude:
+  - drafts
+`
+
+func TestParse(t *testing.T) {
+	job, err := Parse([]byte(jobYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(job.To) != 2 || job.To[1] != "ja" || len(job.Fallbacks) != 2 {
+		t.Errorf("Unexpected lists %v %v", job.To, job.Fallbacks)
+	}
+	if job.Timeout != 30*time.Second || !job.Segment {
+		t.Errorf("Unexpected timeout %s, segment %v", job.Timeout, job.Segment)
+	}
+
+	cfg := job.RetryConfig()
+	if cfg.MaxRetries != 0 || cfg.MaxDelay != 10*time.Second || cfg.BaseDelay != translate.DefaultRetryConfig.BaseDelay {
+		t.Errorf("Unexpected retry config %+v", cfg)
+	}
+	if b := job.BreakerConfig(); b.FailureThreshold != 3 || b.OpenTimeout != translate.DefaultBreakerConfig.OpenTimeout {
+		t.Errorf("Unexpected breaker config %+v", b)
+	}
+
+	tran, err := job.Translator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tran.(*translate.Chain); !ok {
+		t.Errorf("Expected fallback chain, got %T", tran)
+	}
+	if job.model() != translate.OpenaiModelList[translate.ZhiPu].Model {
+		t.Errorf("Unexpected model %q", job.model())
+	}
+
+	opts, err := job.Options()
+	if err != nil || len(opts) == 0 {
+		t.Errorf("Unexpected options %d, %v", len(opts), err)
+	}
+}
+
+func TestParse_JSON(t *testing.T) {
+	job, err := Parse([]byte(`{"provider": "mock", "to": ["zh"], "max_token": 200}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.MaxToken != 200 || job.To[0] != "zh" {
+		t.Errorf("Unexpected job %+v", job)
+	}
+}
+
+const jobTOML = `
+provider = "zhipu"
+fallbacks = ["alibaba", "mock"]
+to = "zh, ja"
+timeout = "30s"
+segment = true
+exclude = ["drafts"]
+
+[retry]
+max_retries = 0
+max_delay = "10s"
+
+[glossary]
+eden = "伊甸"
+`
+
+func TestParseTOML(t *testing.T) {
+	job, err := ParseTOML([]byte(jobTOML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(job.To) != 2 || job.To[1] != "ja" || len(job.Fallbacks) != 2 || len(job.Exclude) != 1 {
+		t.Errorf("Unexpected lists %v %v %v", job.To, job.Fallbacks, job.Exclude)
+	}
+	if job.Timeout != 30*time.Second || !job.Segment || job.Glossary["eden"] != "伊甸" {
+		t.Errorf("Unexpected job %+v", job)
+	}
+	if cfg := job.RetryConfig(); cfg.MaxRetries != 0 || cfg.MaxDelay != 10*time.Second {
+		t.Errorf("Unexpected retry config %+v", cfg)
+	}
+
+	cases := []struct {
+		data string
+		want []string
+	}{
+		{"provider = \"mock\"\nunknown = 1\n", []string{"line 2: unknown:", "unknown key"}},
+		{"provider = \"mock\"\nmax_go = \"many\"\n", []string{"line 2", "max_go"}},
+		{"provider = \"mock\"\n\n[retry]\nbase_delay = \"10s\"\nmax_delay = \"1s\"\n", []string{"line 5: retry.max_delay:"}},
+		{"provider = \"mock\"\nto = [\"zh\", \"xx\"]\n", []string{"line 2: to[1]:", "unknown language"}},
+	}
+	for _, c := range cases {
+		_, err := ParseTOML([]byte(c.data))
+		if err == nil {
+			t.Errorf("Expected error for %q", c.data)
+			continue
+		}
+		for _, want := range c.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Error for %q = %q, want %q", c.data, err, want)
+			}
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := []struct {
+		data string
+		want []string
+	}{
+		{"provider: mock\nunknown: 1\n", []string{"line 2", "unknown"}},
+		{"provider: mock\nmax_go: many\n", []string{"line 2", "many"}},
+		{"provider: nope\n", []string{"line 1: provider:", "unknown provider"}},
+		{"provider: mock\nto:\n  - zh\n  - xx\n", []string{"line 4: to[1]:", "unknown language"}},
+		{"provider: mock\nretry:\n  base_delay: 10s\n  max_delay: 1s\n", []string{"line 4: retry.max_delay:"}},
+		{"provider: mock\nretry:\n  jitter: 2\n", []string{"line 3: retry.jitter:"}},
+		{"provider: mock\nformality: casual\n", []string{"line 2: formality:", "unknown formality"}},
+		{"provider: mock\nto: [zh]\nlocale: [zh-TW, en-GB, xx-YY]\n", []string{"line 3: locale[1]:", "does not match", "locale[2]:", "unknown language"}},
+		{"max_failure_ratio: 2\nfallbacks: mock\n", []string{"line 1: max_failure_ratio:", "line 2: fallbacks[0]:"}},
+		{"provider: mock\nto: [zh, ja]\ncheck_lang: true\n", []string{"line 3: check_lang:", "single target"}},
+		{"provider: mock\ndetect: [off, xx]\nrequest:\n  temperature: 3\n", []string{"detect[0]:", "detect[1]:", "line 4: request.temperature:"}},
+	}
+	for _, c := range cases {
+		_, err := Parse([]byte(c.data))
+		if err == nil {
+			t.Errorf("Expected error for %q", c.data)
+			continue
+		}
+		for _, want := range c.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Error for %q = %q, want %q", c.data, err, want)
+			}
+		}
+	}
+
+	_, err := Parse([]byte("provider: nope\n"))
+	var cfgErr *Error
+	if !errors.As(err, &cfgErr) || cfgErr.Key != "provider" || cfgErr.Line != 1 {
+		t.Errorf("Expected *Error for provider, got %#v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "job.yaml")
+	if err := os.WriteFile(file, []byte("provider: mock\nto: xx\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(file)
+	if err == nil || !strings.Contains(err.Error(), file) || !strings.Contains(err.Error(), "line 2: to[0]") {
+		t.Errorf("Expected error with file and line, got %v", err)
+	}
+
+	// 按扩展名选择格式
+	file = filepath.Join(t.TempDir(), "job.toml")
+	if err := os.WriteFile(file, []byte("provider = \"mock\"\nto = [\"zh\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if job, err := Load(file); err != nil || job.To[0] != "zh" {
+		t.Errorf("Unexpected TOML job %+v, %v", job, err)
+	}
+}
+
+func TestJob_SharedBreakerAndLimiter(t *testing.T) {
+	j := &Job{Provider: "zhipu", Rate: 5, Burst: 2, Breaker: &Breaker{FailureThreshold: 1}}
+	if _, err := j.Translator(); err != nil {
+		t.Fatal(err)
+	}
+	b := translate.ProviderBreaker("zhipu")
+	for b.State() != translate.BreakerOpen {
+		b.Failure()
+	}
+	defer b.Success()
+
+	// 之后的任务不能替换或重置已经打开的熔断器
+	other := *j
+	if _, err := other.Translator(); err != nil {
+		t.Fatal(err)
+	}
+	if got := translate.ProviderBreaker("zhipu"); got != b || got.State() != translate.BreakerOpen {
+		t.Errorf("Expected the open breaker to be kept, got state %s", got.State())
+	}
+
+	if translate.SharedRateLimiter("zhipu", 5, 2) != translate.SharedRateLimiter("zhipu", 5, 2) {
+		t.Error("Expected jobs with the same rate to share a limiter")
+	}
+	if translate.SharedRateLimiter("zhipu", 5, 2) == translate.SharedRateLimiter("alibaba", 5, 2) {
+		t.Error("Expected providers to have separate limiters")
+	}
+}
@@ -0,0 +1,464 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gou-jjjj/eden"
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/prompt"
+	"github.com/gou-jjjj/eden/segment"
+	"github.com/gou-jjjj/eden/tokenizer"
+	"github.com/gou-jjjj/eden/translate"
+)
+
+// Tokenizers 可以在配置中使用的分词器
+var Tokenizers = []string{"rune", "estimate", "tiktoken"}
+
+// detectOff 关闭语言检测
+const detectOff = "off"
+
+// Validate 校验配置，返回所有错误
+func (j *Job) Validate() error {
+	errs := make([]error, 0)
+	add := func(key, format string, args ...any) {
+		errs = append(errs, j.errorf(key, format, args...))
+	}
+
+	providers := translate.Providers()
+	if j.Provider != "" && !slices.Contains(providers, j.Provider) {
+		add("provider", "unknown provider %q, available: %s", j.Provider, strings.Join(providers, ", "))
+	}
+	for i, name := range j.Fallbacks {
+		key := fmt.Sprintf("fallbacks[%d]", i)
+		switch {
+		case j.Provider == "":
+			add(key, "fallbacks require a provider")
+		case !slices.Contains(providers, name):
+			add(key, "unknown provider %q, available: %s", name, strings.Join(providers, ", "))
+		}
+	}
+
+	if j.Tokenizer != "" && !slices.Contains(Tokenizers, j.Tokenizer) {
+		add("tokenizer", "unknown tokenizer %q, available: %s", j.Tokenizer, strings.Join(Tokenizers, ", "))
+	}
+	if j.From != "" {
+		if _, err := lang.Lookup(j.From); err != nil {
+			add("from", "%v", err)
+		}
+	}
+	for i, code := range j.To {
+		key := fmt.Sprintf("to[%d]", i)
+		l, err := lang.Lookup(code)
+		switch {
+		case err != nil:
+			add(key, "%v", err)
+		case l == lang.All:
+			add(key, "target language must be specific")
+		}
+	}
+
+	for _, v := range []struct {
+		key   string
+		value float64
+	}{
+		{"max_go", float64(j.MaxGo)},
+		{"max_token", float64(j.MaxToken)},
+		{"expansion", j.Expansion},
+		{"latency", float64(j.Latency)},
+		{"rate", j.Rate},
+		{"burst", float64(j.Burst)},
+		{"timeout", float64(j.Timeout)},
+		{"file_go", float64(j.FileGo)},
+		{"context_window", float64(j.ContextWindow)},
+	} {
+		if v.value < 0 {
+			add(v.key, "must not be negative")
+		}
+	}
+	if j.MaxFailureRatio < 0 || j.MaxFailureRatio > 1 {
+		add("max_failure_ratio", "must be between 0 and 1")
+	}
+
+	if r := j.Retry; r != nil {
+		if r.MaxRetries != nil && *r.MaxRetries < 0 {
+			add("retry.max_retries", "must not be negative")
+		}
+		if r.BaseDelay < 0 {
+			add("retry.base_delay", "must not be negative")
+		}
+		if r.MaxDelay < 0 {
+			add("retry.max_delay", "must not be negative")
+		}
+		if cfg := j.RetryConfig(); cfg.MaxDelay < cfg.BaseDelay {
+			add("retry.max_delay", "must not be less than base_delay %s", cfg.BaseDelay)
+		}
+		if r.BackoffFactor != 0 && r.BackoffFactor < 1 {
+			add("retry.backoff_factor", "must be at least 1")
+		}
+		if r.Jitter != nil && (*r.Jitter < 0 || *r.Jitter > 1) {
+			add("retry.jitter", "must be between 0 and 1")
+		}
+	}
+	if b := j.Breaker; b != nil {
+		if b.FailureThreshold < 0 {
+			add("breaker.failure_threshold", "must not be negative")
+		}
+		if b.OpenTimeout < 0 {
+			add("breaker.open_timeout", "must not be negative")
+		}
+		if b.HalfOpenProbes < 0 {
+			add("breaker.half_open_probes", "must not be negative")
+		}
+	}
+
+	if j.PromptSingle != "" && j.Prompt == "" {
+		add("prompt_single", "requires prompt")
+	}
+	if j.Formality != "" && !slices.Contains(translate.Formalities, j.Formality) {
+		add("formality", "unknown formality %q, available: %s", j.Formality, strings.Join(translate.Formalities, ", "))
+	}
+	for i, locale := range j.Locale {
+		key := fmt.Sprintf("locale[%d]", i)
+		l := eden.LocaleLang(locale)
+		switch {
+		case l == "":
+			add(key, "unknown language in locale %q", locale)
+		case !slices.Contains(j.targets(), l):
+			add(key, "locale %q does not match any target language", locale)
+		}
+	}
+	for term, tran := range j.Glossary {
+		if strings.TrimSpace(term) == "" || strings.TrimSpace(tran) == "" {
+			add("glossary."+term, "term and translation must not be empty")
+		}
+	}
+	for i, pattern := range j.Include {
+		if !validPattern(pattern) {
+			add(fmt.Sprintf("include[%d]", i), "invalid pattern %q", pattern)
+		}
+	}
+	for i, pattern := range j.Exclude {
+		if !validPattern(pattern) {
+			add(fmt.Sprintf("exclude[%d]", i), "invalid pattern %q", pattern)
+		}
+	}
+
+	for k := range j.Labels {
+		if strings.TrimSpace(k) == "" {
+			add("labels", "label names must not be empty")
+		}
+	}
+	if r := j.Request; r != nil {
+		if r.Temperature != nil && (*r.Temperature < 0 || *r.Temperature > 2) {
+			add("request.temperature", "must be between 0 and 2")
+		}
+		if r.MaxTokens < 0 {
+			add("request.max_tokens", "must not be negative")
+		}
+	}
+	for i, code := range j.Detect {
+		key := fmt.Sprintf("detect[%d]", i)
+		if strings.EqualFold(code, detectOff) {
+			if len(j.Detect) > 1 {
+				add(key, "off can not be combined with languages")
+			}
+			continue
+		}
+		l, err := lang.Lookup(code)
+		switch {
+		case err != nil:
+			add(key, "%v", err)
+		case l == lang.All:
+			add(key, "candidate language must be specific")
+		}
+	}
+	if j.DetectMinConf < 0 || j.DetectMinConf > 1 {
+		add("detect_confidence", "must be between 0 and 1")
+	}
+	if j.CheckLang {
+		targets := j.targets()
+		switch {
+		case len(targets) > 1:
+			add("check_lang", "requires a single target language")
+		case lang.LangMapChecks[targets[0]] == nil:
+			add("check_lang", "no checker for %s", targets[0])
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RetryConfig 重试配置，未设置的字段使用默认值
+func (j *Job) RetryConfig() translate.RetryConfig {
+	cfg := translate.DefaultRetryConfig
+	r := j.Retry
+	if r == nil {
+		return cfg
+	}
+
+	if r.MaxRetries != nil {
+		cfg.MaxRetries = *r.MaxRetries
+	}
+	if r.BaseDelay > 0 {
+		cfg.BaseDelay = r.BaseDelay
+	}
+	if r.MaxDelay > 0 {
+		cfg.MaxDelay = r.MaxDelay
+	}
+	if r.BackoffFactor > 0 {
+		cfg.BackoffFactor = r.BackoffFactor
+	}
+	if r.Jitter != nil {
+		cfg.Jitter = *r.Jitter
+	}
+	return cfg
+}
+
+// BreakerConfig 熔断器配置，未设置的字段使用默认值
+func (j *Job) BreakerConfig() translate.BreakerConfig {
+	cfg := translate.DefaultBreakerConfig
+	b := j.Breaker
+	if b == nil {
+		return cfg
+	}
+
+	if b.FailureThreshold > 0 {
+		cfg.FailureThreshold = b.FailureThreshold
+	}
+	if b.OpenTimeout > 0 {
+		cfg.OpenTimeout = b.OpenTimeout
+	}
+	if b.HalfOpenProbes > 0 {
+		cfg.HalfOpenProbes = b.HalfOpenProbes
+	}
+	return cfg
+}
+
+// Translator 创建翻译器，有备用翻译器时按顺序组成 Chain，没有设置翻译器时返回 nil
+// 配置了熔断器时，服务商还没有共享的熔断器才按配置创建，已有的熔断器保持原状态
+func (j *Job) Translator() (translate.Translate, error) {
+	if j.Provider == "" {
+		return nil, nil
+	}
+
+	opts := make([]translate.OpenaiOpt, 0)
+	if j.Retry != nil {
+		opts = append(opts, translate.WithRetryConfig(j.RetryConfig()))
+	}
+	if j.Timeout > 0 {
+		opts = append(opts, translate.WithTimeout(j.Timeout))
+	}
+	if j.Proxy != "" {
+		opts = append(opts, translate.WithProxy(j.Proxy))
+	}
+	if j.Prompt != "" {
+		tmpl, err := prompt.LoadTemplate(j.Prompt, j.PromptSingle)
+		if err != nil {
+			return nil, j.errorf("prompt", "%v", err)
+		}
+		opts = append(opts, translate.WithPromptTemplate(tmpl))
+	}
+	if j.Examples != "" {
+		loaded, err := prompt.LoadExamples(j.Examples)
+		if err != nil {
+			return nil, j.errorf("examples", "%v", err)
+		}
+		examples := prompt.DefaultExamples.Clone()
+		examples.Set(loaded...)
+		opts = append(opts, translate.WithExamples(examples))
+	}
+
+	names := append([]string{j.Provider}, j.Fallbacks...)
+	trans := make([]translate.Translate, 0, len(names))
+	for _, name := range names {
+		if j.Breaker != nil && name != translate.Mock {
+			translate.RegisterProviderBreaker(name, j.BreakerConfig())
+		}
+		tran, err := translate.NewByName(name, opts...)
+		if err != nil {
+			return nil, j.errorf("provider", "%v", err)
+		}
+		trans = append(trans, tran)
+	}
+
+	if len(trans) == 1 {
+		return trans[0], nil
+	}
+	return translate.Fallback(trans[0], trans[1:]...), nil
+}
+
+// model 配置的模型，未设置时使用主翻译器的模型
+func (j *Job) model() string {
+	if j.Model != "" {
+		return j.Model
+	}
+	return translate.OpenaiModelList[j.Provider].Model
+}
+
+// targets 目标语言，没有配置时为英文，无效的语言代码被忽略
+func (j *Job) targets() []string {
+	langs := make([]string, 0, len(j.To))
+	for _, code := range j.To {
+		if to, err := lang.Lookup(code); err == nil {
+			langs = append(langs, to)
+		}
+	}
+	if len(langs) == 0 {
+		langs = append(langs, lang.EN)
+	}
+	return langs
+}
+
+// detector 按配置创建语言检测器，没有配置时返回 false，使用处理器默认的检测器
+func (j *Job) detector() (*lang.Detector, bool) {
+	if len(j.Detect) == 1 && strings.EqualFold(j.Detect[0], detectOff) {
+		return nil, true
+	}
+	if len(j.Detect) == 0 && j.DetectMinConf == 0 {
+		return nil, false
+	}
+
+	dOpts := make([]lang.DetectorOpt, 0, 2)
+	if len(j.Detect) > 0 {
+		langs := make([]string, 0, len(j.Detect))
+		for _, code := range j.Detect {
+			l, _ := lang.Lookup(code)
+			langs = append(langs, l)
+		}
+		dOpts = append(dOpts, lang.WithCandidates(langs...))
+	}
+	if j.DetectMinConf > 0 {
+		dOpts = append(dOpts, lang.WithMinConfidence(j.DetectMinConf))
+	}
+	return lang.NewDetector(dOpts...), true
+}
+
+// Options 校验配置并生成处理器选项，输入和日志由调用方设置
+func (j *Job) Options() ([]eden.Opt, error) {
+	if err := j.Validate(); err != nil {
+		return nil, err
+	}
+
+	tran, err := j.Translator()
+	if err != nil {
+		return nil, err
+	}
+
+	langs := []string{lang.All}
+	if j.From != "" {
+		langs[0], _ = lang.Lookup(j.From)
+	}
+	langs = append(langs, j.targets()...)
+
+	opts := []eden.Opt{
+		eden.WithLang(langs...),
+		eden.WithModel(j.model()),
+		eden.WithMaxGo(j.MaxGo),
+		eden.WithMaxToken(j.MaxToken),
+		eden.WithExpansion(j.Expansion),
+		eden.WithRequestLatency(j.Latency),
+	}
+	if tran != nil {
+		opts = append(opts, eden.WithProcessFunc(tran))
+	}
+	if j.Output != "" {
+		opts = append(opts, eden.WithOutput(j.Output))
+	}
+
+	switch j.Tokenizer {
+	case "rune":
+		opts = append(opts, eden.WithTokenizer(tokenizer.RuneTokenizer{}))
+	case "estimate":
+		opts = append(opts, eden.WithTokenizer(tokenizer.EstimateTokenizer{}))
+	case "tiktoken":
+		tk, err := tokenizer.NewTiktoken(j.model())
+		if err != nil {
+			return nil, j.errorf("tokenizer", "%v", err)
+		}
+		opts = append(opts, eden.WithTokenizer(tk))
+	}
+
+	if j.Strict {
+		opts = append(opts, eden.WithStrict())
+	}
+	if j.MaxFailureRatio > 0 {
+		opts = append(opts, eden.WithMaxFailureRatio(j.MaxFailureRatio))
+	}
+	if j.Checkpoint != "" {
+		opts = append(opts, eden.WithCheckpoint(j.Checkpoint))
+	}
+	if j.Rate > 0 {
+		// 同一进程中相同配置的任务共享限流器，服务和监视模式下限制的是总速率
+		opts = append(opts, eden.WithRateLimiter(translate.SharedRateLimiter(j.Provider, j.Rate, j.Burst)))
+	}
+	if len(j.Glossary) > 0 {
+		opts = append(opts, eden.WithGlossary(j.Glossary))
+	}
+	if j.Segment {
+		opts = append(opts, eden.WithSegmenter(segment.ForLang(langs[0])))
+	}
+	if j.Summary && tran != nil {
+		s, ok := tran.(translate.Summarizer)
+		if !ok {
+			return nil, j.errorf("summary", "provider %s does not support summaries", j.Provider)
+		}
+		opts = append(opts, eden.WithSummarizer(s))
+	}
+	if j.ContextWindow > 0 {
+		opts = append(opts, eden.WithContextWindow(j.ContextWindow))
+	}
+	if j.Domain != "" {
+		opts = append(opts, eden.WithDomain(j.Domain))
+	}
+	if j.Tone != "" {
+		opts = append(opts, eden.WithTone(j.Tone))
+	}
+	if j.Formality != "" {
+		opts = append(opts, eden.WithFormality(j.Formality))
+	}
+	if len(j.Locale) > 0 {
+		opts = append(opts, eden.WithLocale(j.Locale...))
+	}
+	if j.Audience != "" {
+		opts = append(opts, eden.WithAudience(j.Audience))
+	}
+	if j.JobID != "" {
+		opts = append(opts, eden.WithJobID(j.JobID))
+	}
+	if len(j.Labels) > 0 {
+		opts = append(opts, eden.WithLabels(j.Labels))
+	}
+	if r := j.Request; r != nil {
+		opts = append(opts, eden.WithRequestOptions(translate.ReqOptions{
+			Model:       r.Model,
+			Temperature: r.Temperature,
+			MaxTokens:   r.MaxTokens,
+		}))
+	}
+	if d, ok := j.detector(); ok {
+		opts = append(opts, eden.WithDetector(d))
+	}
+	if j.CheckLang {
+		opts = append(opts, eden.WithLangChecker(lang.LangMapChecks[j.targets()[0]]))
+	}
+	return opts, nil
+}
+
+// BatchOptions 目录批量处理选项，opts 为每个文件的处理器选项
+func (j *Job) BatchOptions(opts []eden.Opt) []eden.BatchOpt {
+	bOpts := []eden.BatchOpt{
+		eden.WithBatchMaxGo(j.MaxGo),
+		eden.WithFileConcurrency(j.FileGo),
+		eden.WithDocxOptions(opts...),
+	}
+	if len(j.Include) > 0 {
+		bOpts = append(bOpts, eden.WithInclude(j.Include...))
+	}
+	if len(j.Exclude) > 0 {
+		bOpts = append(bOpts, eden.WithExclude(j.Exclude...))
+	}
+	return bOpts
+}
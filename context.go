@@ -0,0 +1,115 @@
+package eden
+
+import (
+	"strings"
+
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/translate"
+)
+
+// WithSummarizer 翻译前用 s 概括文档的主题、读者和关键术语，摘要随每个请求发送给翻译器
+// 摘要最多保留 DefaultSummaryTokens 个 token
+// 摘要失败时不影响翻译
+func WithSummarizer(s translate.Summarizer) Opt {
+	return func(p *DocxProcessor) {
+		p.summarizer = s
+	}
+}
+
+// WithContextWindow 每个请求附带分块之前的 n 个片段，已经翻译完成的片段同时附带译文
+// 上下文最多占用 DefaultContextTokens 个 token，超出时丢弃较早的片段
+// 分块并发翻译时之前的译文可能尚未完成，并发数为 1 时总能拿到
+func WithContextWindow(n int) Opt {
+	return func(p *DocxProcessor) {
+		p.contextWindow = n
+	}
+}
+
+// summarize 用文档开头不超过一个分块预算的文本生成摘要
+func (p *DocxProcessor) summarize() {
+	if p.summarizer == nil || len(p.paraSet) == 0 {
+		return
+	}
+
+	budget := p.chunkBudget()
+	tokens := 0
+	builder := strings.Builder{}
+	for _, para := range p.paraSet {
+		for _, s := range para {
+			tokens += p.tokenizer.Count(s)
+			if tokens > budget && builder.Len() > 0 {
+				break
+			}
+			builder.WriteString(s)
+			builder.WriteString("\n")
+		}
+		if tokens > budget {
+			break
+		}
+	}
+
+	req := &translate.SummaryReq{Text: builder.String(), Lang: lang.EN}
+	p.limiter.Wait()
+	summary, err := p.summarizer.Summarize(req)
+
+	// 摘要的用量计入汇总报告
+	p.rw.Lock()
+	p.report.Usage.Merge(req.Usage)
+	p.report.Cost += p.prices.Cost(req.Usage)
+	if err == nil {
+		summary = p.truncateTokens(summary, DefaultSummaryTokens)
+		p.summary = summary
+		p.report.Summary = summary
+	}
+	p.rw.Unlock()
+
+	if p.logger != nil {
+		if err != nil {
+			p.logger.Warn("生成文档摘要失败，不使用摘要继续翻译: %v", err)
+		} else {
+			p.logger.Info("文档摘要: %s", summary)
+		}
+	}
+}
+
+// contextFor 分块的文档上下文，没有摘要也不使用上下文窗口时返回 nil
+func (p *DocxProcessor) contextFor(tg *target, paraIdx int) *translate.DocContext {
+	if p.summary == "" && p.contextWindow <= 0 {
+		return nil
+	}
+
+	ctx := &translate.DocContext{Summary: p.summary}
+	if p.contextWindow > 0 {
+		sources := make([]string, 0, p.contextWindow)
+		for i := paraIdx - 1; i >= 0 && len(sources) < p.contextWindow; i-- {
+			para := p.paraSet[i]
+			for j := len(para) - 1; j >= 0 && len(sources) < p.contextWindow; j-- {
+				sources = append(sources, para[j])
+			}
+		}
+
+		p.rw.Lock()
+		pairs := make([]translate.Pair, 0, len(sources))
+		for _, source := range sources {
+			pairs = append(pairs, translate.Pair{Source: source, Target: tg.tranParaSet[source]})
+		}
+		p.rw.Unlock()
+
+		// 从最近的片段开始保留，超出上限时丢弃较早的片段
+		tokens, keep := 0, 0
+		for _, pair := range pairs {
+			tokens += p.tokenizer.Count(pair.Source) + p.tokenizer.Count(pair.Target)
+			if tokens > DefaultContextTokens {
+				break
+			}
+			keep++
+		}
+		for i := keep - 1; i >= 0; i-- {
+			ctx.Previous = append(ctx.Previous, pairs[i])
+		}
+	}
+	if ctx.Empty() {
+		return nil
+	}
+	return ctx
+}
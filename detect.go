@@ -0,0 +1,65 @@
+package eden
+
+import (
+	"github.com/gou-jjjj/eden/lang"
+)
+
+// WithDetector 源语言为 lang.All 时用 d 检测文档、分块和片段的语言，检测到的语言作为请求的源语言
+// 默认使用 lang.DefaultDetector，d 为空时不检测
+func WithDetector(d *lang.Detector) Opt {
+	return func(p *DocxProcessor) {
+		p.detector = d
+	}
+}
+
+// detecting 需要检测源语言
+func (p *DocxProcessor) detecting() bool {
+	return p.detector != nil && p.fromLang == lang.All
+}
+
+// detectDocument 检测每个片段的语言，按字母数加权合并为分块和文档的语言
+func (p *DocxProcessor) detectDocument() {
+	p.detected = lang.Detection{}
+	p.chunkLangs = nil
+	if !p.detecting() || len(p.paraSet) == 0 {
+		return
+	}
+
+	all := make([]lang.Detection, 0)
+	p.chunkLangs = make([]lang.Detection, len(p.paraSet))
+	for i, para := range p.paraSet {
+		dets := make([]lang.Detection, len(para))
+		for j, text := range para {
+			dets[j] = p.detector.Detect(text)
+			if dets[j].Lang != lang.All {
+				p.segSet[i][j].Lang = dets[j].Lang
+				p.segSet[i][j].Confidence = dets[j].Confidence
+			}
+		}
+		p.chunkLangs[i] = p.detector.Combine(dets...)
+		all = append(all, dets...)
+	}
+	p.detected = p.detector.Combine(all...)
+
+	detected := p.detected
+	p.rw.Lock()
+	p.report.Detected = &detected
+	p.rw.Unlock()
+	if p.logger != nil {
+		p.logger.Info("检测到源语言: %s, 置信度: %.2f", detected.Lang, detected.Confidence)
+	}
+}
+
+// fromFor 分块请求的源语言，分块语言不确定时使用文档的语言，都不确定时为 lang.All
+func (p *DocxProcessor) fromFor(idx int) string {
+	if !p.detecting() {
+		return p.fromLang
+	}
+	if idx < len(p.chunkLangs) && p.chunkLangs[idx].Lang != lang.All {
+		return p.chunkLangs[idx].Lang
+	}
+	if p.detected.Lang != "" {
+		return p.detected.Lang
+	}
+	return lang.All
+}
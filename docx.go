@@ -1,7 +1,10 @@
 package eden
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
@@ -11,6 +14,9 @@ import (
 
 	"github.com/gou-jjjj/eden/lang"
 	"github.com/gou-jjjj/eden/logger"
+	"github.com/gou-jjjj/eden/prompt"
+	"github.com/gou-jjjj/eden/segment"
+	"github.com/gou-jjjj/eden/tokenizer"
 	"github.com/gou-jjjj/eden/translate"
 	"github.com/gou-jjjj/unioffice/document"
 	"github.com/panjf2000/ants"
@@ -32,19 +38,63 @@ func WithOutput(dir string) Opt {
 	}
 }
 
+// WithReader 从内存读取文档，不访问文件系统
+func WithReader(r io.ReaderAt, size int64) Opt {
+	return func(p *DocxProcessor) {
+		p.reader = r
+		p.readerSize = size
+	}
+}
+
+// WithBytes 从字节切片读取文档
+func WithBytes(b []byte) Opt {
+	return WithReader(bytes.NewReader(b), int64(len(b)))
+}
+
+// WithWriter 把翻译后的文档写入 w，而不是输出目录
+func WithWriter(w io.Writer) Opt {
+	return func(p *DocxProcessor) {
+		p.writer = w
+	}
+}
+
+// WithTargetWriter 多个目标语言写入内存时，按语言获取对应的 io.Writer
+func WithTargetWriter(fn func(to string) (io.Writer, error)) Opt {
+	return func(p *DocxProcessor) {
+		p.targetWriter = fn
+	}
+}
+
+// WithLogWriter 把日志写入 w，不再创建日志文件
+func WithLogWriter(w io.Writer) Opt {
+	return func(p *DocxProcessor) {
+		p.logWriter = w
+	}
+}
+
+// WithName 设置文档名，用于日志和输出文件名，从内存读取时使用
+func WithName(name string) Opt {
+	return func(p *DocxProcessor) {
+		p.fileName = name
+	}
+}
+
+// WithLang 设置语言：一个参数为目标语言；多个参数时第一个为源语言，其余为目标语言
+// 多个目标语言时只提取一次文本，所有语言在同一个协程池中翻译，每种语言输出一个文件
 func WithLang(lg ...string) Opt {
-	to := lang.EN
+	from := lang.All
+	to := []string{lang.EN}
 	if len(lg) == 1 {
-		to = lg[0]
+		to = lg
 	}
-	from := lang.All
-	if len(lg) == 2 {
+	if len(lg) >= 2 {
 		from = lg[0]
-		to = lg[1]
+		to = lg[1:]
 	}
 	return func(p *DocxProcessor) {
 		p.fromLang = from
-		p.toLang = to
+		p.toLangs = to
+		p.toLang = to[0]
 	}
 }
 
@@ -54,6 +104,7 @@ func WithProcessFunc(f translate.Translate) Opt {
 	}
 }
 
+// WithLangChecker 判断文本是否已经是目标语言的检查器，只能用于单个目标语言
 func WithLangChecker(checker lang.LanguageChecker) Opt {
 	return func(p *DocxProcessor) {
 		p.langChecker = checker
@@ -72,29 +123,157 @@ func WithLogger(logger *logger.DocxLogger) Opt {
 	}
 }
 
+// WithMaxToken 单个分块最多包含的原文 token 数，不设置时按模型上下文自动计算
 func WithMaxToken(maxToken int) Opt {
 	return func(p *DocxProcessor) {
 		p.maxToken = maxToken
 	}
 }
 
+// WithTokenizer 设置计算分块大小使用的分词器
+func WithTokenizer(tk tokenizer.Tokenizer) Opt {
+	return func(p *DocxProcessor) {
+		p.tokenizer = tk
+	}
+}
+
+// WithModel 设置目标模型，用于选择分词器和上下文限制，不设置时从翻译器获取
+func WithModel(model string) Opt {
+	return func(p *DocxProcessor) {
+		p.model = model
+	}
+}
+
+// WithPriceTable 设置计算费用使用的模型价格表
+func WithPriceTable(prices translate.PriceTable) Opt {
+	return func(p *DocxProcessor) {
+		p.prices = prices
+	}
+}
+
+// WithDryRun 只估算请求数、token、费用和耗时，不调用翻译器也不保存文件
+func WithDryRun() Opt {
+	return func(p *DocxProcessor) {
+		p.dryRun = true
+	}
+}
+
+// WithRequestLatency 设置估算耗时使用的单次请求耗时，不设置时按输出 token 数估算
+func WithRequestLatency(latency time.Duration) Opt {
+	return func(p *DocxProcessor) {
+		p.latency = latency
+	}
+}
+
+// WithStrict 严格模式，任意分块翻译失败时任务失败且不保存文件
+// 同时设置了 WithMaxFailureRatio 时以失败占比为准，与选项顺序无关
+func WithStrict() Opt {
+	return func(p *DocxProcessor) {
+		p.strict = true
+	}
+}
+
+// WithMaxFailureRatio 失败分块占比超过 ratio 时任务失败且不保存文件
+func WithMaxFailureRatio(ratio float64) Opt {
+	return func(p *DocxProcessor) {
+		p.maxFailRatio = ratio
+		p.hasFailRatio = true
+	}
+}
+
+// WithCheckpoint 把已完成的分块保存到 dir 下的检查点文件，中断后再次运行时从检查点恢复
+func WithCheckpoint(dir string) Opt {
+	return func(p *DocxProcessor) {
+		p.checkpointDir = dir
+	}
+}
+
+// WithExpansion 设置译文相对原文的 token 膨胀系数
+func WithExpansion(expansion float64) Opt {
+	return func(p *DocxProcessor) {
+		p.expansion = expansion
+	}
+}
+
+// WithPool 使用外部协程池翻译分块，多个处理器可以共享同一个池，处理结束后不会释放
+func WithPool(pool *ants.Pool) Opt {
+	return func(p *DocxProcessor) {
+		p.pool = pool
+	}
+}
+
+// WithRateLimiter 每个分块请求翻译器前等待限流器的令牌
+func WithRateLimiter(limiter *translate.RateLimiter) Opt {
+	return func(p *DocxProcessor) {
+		p.limiter = limiter
+	}
+}
+
+// WithGlossary 设置术语表，每个分块只把其中出现的术语发送给翻译器
+func WithGlossary(glossary map[string]string) Opt {
+	return func(p *DocxProcessor) {
+		p.glossary = glossary
+	}
+}
+
 // DocxProcessor DOCX 处理器
 type DocxProcessor struct {
-	fromLang    string
-	toLang      string
-	f           *document.Document
-	closeFunc   func() error
-	fileName    string
-	paraSet     []translate.Paragraph
-	tranParaSet map[string]string
-	maxToken    int
-
-	inputPath   string
-	outputDir   string
-	maxGo       int
-	process     translate.Translate
-	langChecker lang.LanguageChecker
-	logger      *logger.DocxLogger
+	fromLang      string
+	toLang        string   // 第一个目标语言
+	toLangs       []string // 所有目标语言
+	targets       []*target
+	f             *document.Document
+	closeFunc     func() error
+	fileName      string
+	paraSet       []translate.Paragraph
+	segSet        [][]translate.Segment // 每个分块中片段的来源信息，与 paraSet 对应
+	maxToken      int
+	tokenizer     tokenizer.Tokenizer
+	model         string
+	expansion     float64
+	prices        translate.PriceTable
+	report        *Report
+	dryRun        bool
+	latency       time.Duration
+	strict        bool
+	maxFailRatio  float64
+	hasFailRatio  bool
+	failLimit     float64 // 允许的失败分块占比，在 Process 中确定，小于 0 表示不限制
+	checkpointDir string
+	dirty         bool // 文档已写入译文
+	glossary      map[string]string
+	segmenter     segment.Segmenter
+	summarizer    translate.Summarizer
+	summary       string
+	contextWindow int
+	domain        string
+	tone          string
+	formality     string
+	audience      string
+	locales       []string
+	jobID         string
+	labels        map[string]string
+	reqOptions    translate.ReqOptions
+	detector      *lang.Detector
+	detected      lang.Detection   // 检测到的文档语言
+	chunkLangs    []lang.Detection // 检测到的每个分块的语言，与 paraSet 对应
+	handlers      []EventHandler
+	progress      progress
+	evMu          sync.Mutex
+
+	inputPath    string
+	outputDir    string
+	reader       io.ReaderAt
+	readerSize   int64
+	writer       io.Writer
+	targetWriter func(to string) (io.Writer, error)
+	logWriter    io.Writer
+	maxGo        int
+	pool         *ants.Pool
+	limiter      *translate.RateLimiter
+	process      translate.Translate
+	langChecker  lang.LanguageChecker
+	logger       *logger.DocxLogger
 
 	rw sync.Mutex
 	wg sync.WaitGroup
@@ -102,7 +281,7 @@ type DocxProcessor struct {
 
 // NewDocxProcessor 创建新的 DOCX 处理器
 func NewDocxProcessor(opts ...Opt) *DocxProcessor {
-	p := &DocxProcessor{}
+	p := &DocxProcessor{detector: lang.DefaultDetector}
 	for _, opt := range opts {
 		if opt != nil {
 			opt(p)
@@ -112,38 +291,88 @@ func NewDocxProcessor(opts ...Opt) *DocxProcessor {
 	if p.maxGo <= 0 {
 		p.maxGo = 1
 	}
-	if p.maxToken <= 0 {
-		p.maxToken = 1 << 16 // 默认最大文字数 65536
+	if p.model == "" {
+		if m, ok := p.process.(translate.ModelTranslate); ok {
+			p.model = m.Model()
+		}
+	}
+	if p.tokenizer == nil {
+		p.tokenizer = tokenizer.ForModel(p.model)
+	}
+	if p.expansion <= 0 {
+		p.expansion = tokenizer.DefaultExpansion
 	}
 
-	p.paraSet = make([]translate.Paragraph, 0)
-	p.tranParaSet = make(map[string]string, 0)
-	p.langChecker = lang.LangMapChecks[p.toLang]
-	p.fileName = strings.Split(filepath.Base(p.inputPath), ".")[0]
+	if p.prices == nil {
+		p.prices = translate.DefaultPriceTable
+	}
 
-	_, err := os.Stat(p.outputDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			_ = os.MkdirAll(p.outputDir, os.ModePerm)
-		}
+	if len(p.toLangs) == 0 {
+		p.toLang = lang.EN
+		p.toLangs = []string{lang.EN}
 	}
 
-	// 初始化日志记录器
-	if p.logger == nil {
-		lg, err := logger.NewLogger(false, p.outputDir, p.fileName)
-		if err != nil {
-			fmt.Printf("警告: 无法创建日志记录器: %v\n", err)
-		} else {
-			p.logger = lg
-		}
+	// 调用方指定的语言检查器只对应一种语言，多个目标语言时由 validate 报错
+	checker := p.langChecker
+	if len(p.toLangs) > 1 {
+		checker = nil
+	}
+	p.targets = make([]*target, 0, len(p.toLangs))
+	for _, to := range p.toLangs {
+		tg := newTarget(p.fromLang, to, checker)
+		tg.locale = p.localeFor(to)
+		p.targets = append(p.targets, tg)
+	}
+	p.report = p.targets[0].report
+	if len(p.targets) > 1 {
+		p.report = newReport()
+	}
+
+	p.paraSet = make([]translate.Paragraph, 0)
+	if p.fileName == "" && p.inputPath != "" {
+		p.fileName = outputName(p.inputPath)
+	}
+
+	// 调用方指定的日志位置在构造时就绪，日志文件和输出目录到 Process 时才创建
+	if p.logger == nil && p.logWriter != nil {
+		p.logger = logger.NewWriterLogger(p.logWriter, false)
 	}
 
 	return p
 }
 
-// LoadFile 从 DOCX 文件中加载文档
+// prepareOutput 创建日志文件，只提取文本和试运行时不创建任何文件，从内存读写时不创建日志文件
+func (p *DocxProcessor) prepareOutput() {
+	if p.logger != nil || p.dryRun || p.reader != nil || p.writer != nil {
+		return
+	}
+	lg, err := logger.NewLogger(false, p.outputDir, p.fileName)
+	if err != nil {
+		fmt.Printf("警告: 无法创建日志记录器: %v\n", err)
+		return
+	}
+	p.logger = lg
+}
+
+// validate 检查无法同时使用的选项
+func (p *DocxProcessor) validate() error {
+	if len(p.targets) <= 1 {
+		return nil
+	}
+	// 多个目标语言写入内存时，必须能按语言获取 io.Writer
+	if p.writer != nil && p.targetWriter == nil {
+		return fmt.Errorf("multiple target languages require WithTargetWriter instead of WithWriter")
+	}
+	// 语言检查器只能判断一种语言，不能用于所有目标语言
+	if p.langChecker != nil {
+		return fmt.Errorf("WithLangChecker requires a single target language, got %d", len(p.targets))
+	}
+	return nil
+}
+
+// LoadFile 从 DOCX 文件或内存中加载文档
 func (p *DocxProcessor) LoadFile() error {
-	if p.inputPath == "" {
+	if p.inputPath == "" && p.reader == nil {
 		err := fmt.Errorf("input path is required")
 		if p.logger != nil {
 			p.logger.LogFileLoad(false, "", err)
@@ -151,7 +380,7 @@ func (p *DocxProcessor) LoadFile() error {
 		return err
 	}
 
-	f, err := document.Open(p.inputPath)
+	f, err := p.openDocument()
 	if err != nil {
 		if p.logger != nil {
 			p.logger.LogFileLoad(false, p.inputPath, err)
@@ -165,6 +394,35 @@ func (p *DocxProcessor) LoadFile() error {
 	if p.logger != nil {
 		p.logger.LogFileLoad(true, p.inputPath, nil)
 	}
+	p.emit(Event{Type: EventLoaded, Chunk: -1, Path: p.inputPath})
+	return nil
+}
+
+// openDocument 从文件或内存打开文档
+func (p *DocxProcessor) openDocument() (*document.Document, error) {
+	if p.reader != nil {
+		return document.Read(p.reader, p.readerSize)
+	}
+	return document.Open(p.inputPath)
+}
+
+// reloadFile 重新加载原文档，用于写入下一个目标语言的译文
+func (p *DocxProcessor) reloadFile() error {
+	if p.closeFunc != nil {
+		_ = p.closeFunc()
+		p.closeFunc = nil
+	}
+
+	f, err := p.openDocument()
+	if err != nil {
+		if p.logger != nil {
+			p.logger.LogFileLoad(false, p.inputPath, err)
+		}
+		return err
+	}
+	p.f = f
+	p.closeFunc = f.Close
+	p.dirty = false
 	return nil
 }
 
@@ -175,17 +433,19 @@ func (p *DocxProcessor) ExtractText() error {
 	segmentCount := 0
 	tableCount := len(p.f.Tables())
 	paraTmp := make(translate.Paragraph, 0, 1<<8)
-	caluText := strings.Builder{}
+	segTmp := make([]translate.Segment, 0, 1<<8)
+	budget := p.chunkBudget()
+	seqTokens := p.tokenizer.Count(translate.Seq)
+	caluTokens := 0
+	p.paraSet = make([]translate.Paragraph, 0)
+	p.segSet = make([][]translate.Segment, 0)
 
-	addText := func(s *strings.Builder, str string, clearOld bool) {
-		if clearOld {
-			s.Reset()
-		}
-		s.WriteString(str)
-		s.WriteString(translate.Seq)
+	if p.logger != nil {
+		p.logger.Info("分块大小: %d tokens, 分词器: %s, 模型: %s", budget, p.tokenizer.Name(), p.model)
 	}
 
 	paragraphs := p.f.Paragraphs()
+	bodyParas := len(paragraphs) - cellParagraphs(p.f)
 	for idx, paragraph := range paragraphs {
 		paragraphCount++
 		runs := paragraph.Runs()
@@ -193,12 +453,13 @@ func (p *DocxProcessor) ExtractText() error {
 			continue
 		}
 
-		for _, r := range runs {
-			text := r.Text()
-
+		style := p.styleName(paragraph)
+		for segIdx, text := range p.segments(runs) {
 			// 语言检查
-			if trimText := strings.TrimSpace(text); strings.TrimSpace(trimText) == "" || (p.langChecker != nil && p.langChecker.Check(trimText)) {
-				p.logger.Info(fmt.Sprintf("忽略文本块[%v]", text))
+			if trimText := strings.TrimSpace(text); trimText == "" || p.allTargetLang(trimText) {
+				if p.logger != nil {
+					p.logger.Info("忽略文本块[%v]", text)
+				}
 				continue
 			}
 
@@ -208,33 +469,54 @@ func (p *DocxProcessor) ExtractText() error {
 			if p.logger != nil {
 				p.logger.LogParagraphProcessing(segmentCount, text, true)
 			}
-			addText(&caluText, text, false)
+			seg := translate.Segment{
+				ID:        segmentID(idx, segIdx),
+				Part:      partDocument,
+				Paragraph: idx,
+				Style:     style,
+				TableCell: idx >= bodyParas,
+			}
+			tokens := p.tokenizer.Count(text) + seqTokens
+			if note := seg.Note(); note != "" {
+				// 片段说明随请求发送，同样占用预算
+				tokens += p.tokenizer.Count(prompt.NoteLine(len(paraTmp)+1, note))
+			}
+			caluTokens += tokens
 
 			// 检查长度
-			if len([]rune(caluText.String())) > p.maxToken && len(paraTmp) > 0 {
+			if caluTokens > budget && len(paraTmp) > 0 {
 				p.paraSet = append(p.paraSet, paraTmp)
+				p.segSet = append(p.segSet, segTmp)
 				paraTmp = make(translate.Paragraph, 0)
-				addText(&caluText, text, true)
+				segTmp = make([]translate.Segment, 0)
+				caluTokens = tokens
 			}
 			paraTmp = append(paraTmp, text)
+			segTmp = append(segTmp, seg)
 		}
+	}
 
-		// 最后
-		if idx == len(paragraphs)-1 && len(paraTmp) > 0 {
-			p.paraSet = append(p.paraSet, paraTmp)
-			paraTmp = make(translate.Paragraph, 0)
-			caluText.Reset()
-		}
+	// 最后一个分块，最后的段落为空时也要写出
+	if len(paraTmp) > 0 {
+		p.paraSet = append(p.paraSet, paraTmp)
+		p.segSet = append(p.segSet, segTmp)
 	}
+	linkSegments(p.segSet, p.paraSet)
+	p.detectDocument()
 
 	if p.logger != nil {
 		p.logger.LogTextExtraction(paragraphCount, segmentCount, tableCount, totalCount)
 	}
 
+	p.evMu.Lock()
+	p.progress.total = len(p.paraSet) * len(p.targets)
+	p.evMu.Unlock()
+	p.emit(Event{Type: EventExtracted, Chunk: -1})
+
 	return nil
 }
 
-// ProcessText 处理文本内容
+// ProcessText 处理文本内容，所有目标语言的分块在同一个协程池中翻译
 func (p *DocxProcessor) ProcessText() {
 	if p.process == nil {
 		if p.logger != nil {
@@ -251,70 +533,137 @@ func (p *DocxProcessor) ProcessText() {
 	}
 
 	if p.logger != nil {
-		p.logger.Info("开始翻译%d个分块", len(p.paraSet))
+		p.logger.Info("开始翻译%d个分块，目标语言: %s", len(p.paraSet), strings.Join(p.toLangs, ","))
 	}
 
-	pool, _ := ants.NewPool(p.maxGo,
-		ants.WithMaxBlockingTasks(1<<20),
-		ants.WithPreAlloc(true),
-		ants.WithExpiryDuration(1))
+	p.evMu.Lock()
+	p.progress.tranStart = time.Now()
+	p.evMu.Unlock()
+
+	pool := p.pool
+	if pool == nil {
+		pool, _ = ants.NewPool(p.maxGo,
+			ants.WithMaxBlockingTasks(1<<20),
+			ants.WithPreAlloc(true),
+			ants.WithExpiryDuration(1))
+		defer pool.Release()
+	}
 
-	for k, paragraph := range p.paraSet {
-		paraIdx := k
-		paraCopy := paragraph
-		paraStr := strings.Join(paraCopy, "|")
-		if p.langChecker != nil && p.langChecker.Check(paraStr) {
-			if p.logger != nil {
-				p.logger.Info("翻译跳过:%d [%s]", k, paraStr)
-			}
+	for _, tg := range p.targets {
+		for k, paragraph := range p.paraSet {
+			p.submitChunk(pool, tg, k, paragraph)
+		}
+	}
+
+	p.wg.Wait()
+	for _, tg := range p.targets {
+		tg.report.sortChunks()
+	}
+}
+
+// submitChunk 把一个分块的某个目标语言翻译提交到协程池
+func (p *DocxProcessor) submitChunk(pool *ants.Pool, tg *target, paraIdx int, paraCopy translate.Paragraph) {
+	if tg.skipChunk(paraCopy) {
+		if p.logger != nil {
+			p.logger.Info("翻译跳过:%d->%s [%s]", paraIdx, tg.to, strings.Join(paraCopy, "|"))
+		}
 
+		p.rw.Lock()
+		tg.tranParaSet = combineMap(tg.tranParaSet, fillMap(paraCopy))
+		tg.report.addChunk(ChunkResult{Index: paraIdx, Status: ChunkSkipped, Segments: paraCopy}, p.prices)
+		p.rw.Unlock()
+		p.emit(Event{Type: EventChunkSkipped, Chunk: paraIdx, Lang: tg.to})
+		return
+	}
+
+	// 检查点中已有的分块直接使用
+	id := chunkID(paraIdx, paraCopy)
+	if tg.ckpt != nil {
+		if t, ok := tg.ckpt.get(id); ok && len(t) == len(paraCopy) {
 			p.rw.Lock()
-			p.tranParaSet = combineMap(p.tranParaSet, fillMap(paraCopy))
+			tg.tranParaSet = combineMap(tg.tranParaSet, fillMap(paraCopy, t))
+			tg.report.addChunk(ChunkResult{Index: paraIdx, Status: ChunkTranslated, Segments: paraCopy, Resumed: true}, p.prices)
 			p.rw.Unlock()
-			continue
+			p.emit(Event{Type: EventChunkSkipped, Chunk: paraIdx, Lang: tg.to})
+			return
 		}
+	}
 
-		p.wg.Add(1)
-		_ = pool.Submit(func() {
-			defer p.wg.Done()
+	p.wg.Add(1)
+	_ = pool.Submit(func() {
+		defer p.wg.Done()
 
-			// 记录翻译请求
-			if p.logger != nil {
-				text := strings.Join(paraCopy, " ")
-				p.logger.LogTranslationRequest(paraIdx, p.fromLang, p.toLang, text)
-			}
+		// 记录翻译请求
+		if p.logger != nil {
+			text := strings.Join(paraCopy, " ")
+			p.logger.LogTranslationRequest(paraIdx, p.fromLang, tg.to, text)
+		}
 
-			t, err := p.process.T(&translate.TranReq{
-				From:  p.fromLang,
-				To:    p.toLang,
-				Paras: paraCopy,
-			})
+		p.emit(Event{Type: EventChunkStarted, Chunk: paraIdx, Lang: tg.to})
+		req := &translate.TranReq{
+			From:     p.fromFor(paraIdx),
+			To:       tg.to,
+			Paras:    paraCopy,
+			Glossary: p.glossaryFor(paraCopy),
+			Context:  p.contextFor(tg, paraIdx),
+			Segments: p.segmentsFor(paraIdx),
+			Meta:     p.metaFor(paraIdx),
+			Options:  p.reqOptions,
+			OnRetry: func(attempt int, err error) {
+				p.emit(Event{Type: EventChunkRetried, Chunk: paraIdx, Lang: tg.to, Attempt: attempt, Err: err})
+			},
+		}
+		p.styleReq(tg, req)
+		p.limiter.Wait()
+		t, err := p.process.T(req)
 
-			// 记录翻译响应
-			if p.logger != nil {
-				if err != nil {
-					p.logger.LogTranslationResponse(paraIdx, false, "", err)
-					return
-				} else {
-					translatedText := strings.Join(t, "|")
-					p.logger.LogTranslationResponse(paraIdx, true, translatedText, nil)
-				}
+		// 失败的请求同样计费，用量都需要记录
+		res := ChunkResult{Index: paraIdx, Status: ChunkTranslated, Segments: paraCopy, Usage: req.Usage}
+		if err != nil {
+			res.Status = ChunkFailed
+			res.Err = err
+			res.Error = err.Error()
+		}
+
+		// 记录翻译响应
+		if p.logger != nil {
+			if err != nil {
+				p.logger.LogTranslationResponse(paraIdx, false, "", err)
+			} else {
+				translatedText := strings.Join(t, "|")
+				p.logger.LogTranslationResponse(paraIdx, true, translatedText, nil)
 			}
+		}
 
-			p.rw.Lock()
-			p.tranParaSet = combineMap(p.tranParaSet, fillMap(paraCopy, t))
-			p.rw.Unlock()
-		})
-	}
+		p.rw.Lock()
+		tg.report.addChunk(res, p.prices)
+		if err == nil {
+			tg.tranParaSet = combineMap(tg.tranParaSet, fillMap(paraCopy, t))
+		}
+		p.rw.Unlock()
 
-	p.wg.Wait()
-	pool.Release()
+		if err != nil {
+			p.emit(Event{Type: EventChunkFailed, Chunk: paraIdx, Lang: tg.to, Err: err})
+			return
+		}
+		if tg.ckpt != nil {
+			if err := tg.ckpt.save(id, paraCopy, t); err != nil && p.logger != nil {
+				p.logger.Warn("保存检查点失败: %v", err)
+			}
+		}
+		p.emit(Event{Type: EventChunkSucceeded, Chunk: paraIdx, Lang: tg.to})
+	})
 }
 
-// WriteChanges 将处理后的内容写回 DOCX 文件
+// WriteChanges 将第一个目标语言的翻译结果写回 DOCX 文件
 func (p *DocxProcessor) WriteChanges() {
+	p.writeChanges(p.targets[0])
+}
+
+// writeChanges 将指定目标语言的翻译结果写回 DOCX 文件
+func (p *DocxProcessor) writeChanges(tg *target) {
 	if p.logger != nil {
-		p.logger.Info("开始将翻译结果写回文档")
+		p.logger.Info("开始将翻译结果写回文档: %s", tg.to)
 	}
 
 	paragraphs := p.f.Paragraphs()
@@ -324,10 +673,23 @@ func (p *DocxProcessor) WriteChanges() {
 			continue
 		}
 
+		if p.segmenter != nil {
+			texts := runTexts(runs)
+			for i, text := range rewriteRuns(p.segmenter, texts, tg.tranParaSet) {
+				if text != texts[i] {
+					runs[i].ClearContent()
+					if text != "" {
+						runs[i].AddText(text)
+					}
+				}
+			}
+			continue
+		}
+
 		for _, r := range runs {
 			k := r.Text()
 
-			if tranStr, ok := p.tranParaSet[k]; ok {
+			if tranStr, ok := tg.tranParaSet[k]; ok {
 				r.ClearContent()
 				r.AddText(tranStr)
 			}
@@ -337,17 +699,51 @@ func (p *DocxProcessor) WriteChanges() {
 	if p.logger != nil {
 		p.logger.Info("翻译结果写回完成")
 	}
+	p.dirty = true
+	p.emit(Event{Type: EventWritten, Chunk: -1, Lang: tg.to})
 }
 
-// Process 执行完整的 DOCX 处理流程
-func (p *DocxProcessor) Process() error {
+// Process 执行完整的 DOCX 处理流程，返回处理报告
+// 多个目标语言时返回汇总报告，每种语言的报告在 Targets 中
+func (p *DocxProcessor) Process() (report *Report, err error) {
 	startTime := time.Now()
+	p.evMu.Lock()
+	p.progress.start = startTime
+	p.evMu.Unlock()
+	defer func() {
+		p.emit(Event{Type: EventDone, Chunk: -1, Path: p.report.Output, Err: err})
+	}()
+
+	input := p.inputPath
+	if input == "" {
+		input = p.fileName
+	}
+	translator := ""
+	if p.process != nil {
+		translator = p.process.Name()
+	}
+	p.failLimit = p.failureLimit()
+	p.prepareOutput()
+	p.report.Input = input
+	p.report.From = p.fromLang
+	p.report.To = strings.Join(p.toLangs, ",")
+	p.report.Translator = translator
+	for _, tg := range p.targets {
+		tg.report.Input = input
+		tg.report.Translator = translator
+	}
+	if len(p.targets) > 1 {
+		p.report.Targets = make([]*Report, 0, len(p.targets))
+		for _, tg := range p.targets {
+			p.report.Targets = append(p.report.Targets, tg.report)
+		}
+	}
 
 	// 记录翻译开始
 	if p.logger != nil {
-		p.logger.LogTranslationStart(p.inputPath, p.fromLang, p.toLang)
+		p.logger.LogTranslationStart(p.inputPath, p.fromLang, p.report.To)
 		p.logger.Info("翻译器:%+v,文件名字:%+v,翻译最大并发数量:%+v",
-			p.process.Name(), p.fileName, p.maxGo)
+			translator, p.fileName, p.maxGo)
 	}
 
 	defer func() {
@@ -361,12 +757,19 @@ func (p *DocxProcessor) Process() error {
 		}
 	}()
 
+	if err = p.validate(); err != nil {
+		if p.logger != nil {
+			p.logger.Error("%v", err)
+		}
+		return p.report, err
+	}
+
 	// 1. 加载文件
 	if err := p.LoadFile(); err != nil {
 		if p.logger != nil {
 			p.logger.LogTranslationEnd("", false, time.Since(startTime))
 		}
-		return err
+		return p.report, err
 	}
 
 	// 2. 提取文本
@@ -374,28 +777,220 @@ func (p *DocxProcessor) Process() error {
 		if p.logger != nil {
 			p.logger.LogTranslationEnd("", false, time.Since(startTime))
 		}
-		return err
+		return p.report, err
+	}
+
+	// 试运行只估算，不翻译也不保存
+	if p.dryRun {
+		p.report.Estimate = p.estimate()
+		p.report.Duration = time.Since(startTime)
+		if p.logger != nil {
+			est := p.report.Estimate
+			p.logger.LogEstimate(est.Requests, est.PromptTokens, est.CompletionTokens, est.Cost, est.Duration)
+		}
+		return p.report, nil
+	}
+
+	// 生成文档摘要，所有目标语言共用
+	p.summarize()
+
+	// 打开检查点，恢复上次未完成的进度
+	if p.checkpointDir != "" {
+		for _, tg := range p.targets {
+			if err := p.openCheckpoint(tg); err != nil {
+				if p.logger != nil {
+					p.logger.Warn("检查点不可用，将从头翻译: %v", err)
+				}
+				continue
+			}
+			defer tg.ckpt.close()
+		}
 	}
 
 	// 3. 处理文本
 	p.ProcessText()
 
-	// 4. 写回修改
-	p.WriteChanges()
+	// 4. 逐个目标语言写回并保存
+	errs := make([]error, 0)
+	outputs := make([]string, 0, len(p.targets))
+	for _, tg := range p.targets {
+		if len(p.targets) > 1 {
+			p.report.merge(tg.report)
+		}
 
-	// 5. 保存文件
-	outPath := path.Join(p.outputDir, fmt.Sprintf("%s_%s.docx", p.fileName, lang.LangNames[p.toLang]))
-	err := p.f.SaveToFile(outPath)
+		outPath, saveErr := p.saveTarget(tg)
+		tg.report.Duration = time.Since(startTime)
+		if saveErr != nil {
+			errs = append(errs, saveErr)
+			continue
+		}
+		outputs = append(outputs, outPath)
+	}
+	if len(p.targets) > 1 {
+		p.report.Output = strings.Join(outputs, ",")
+	}
+	p.report.Duration = time.Since(startTime)
+	err = errors.Join(errs...)
+
+	// 记录翻译结束
+	if p.logger != nil {
+		p.logUsage()
+		p.logger.LogTranslationEnd(p.report.Output, err == nil, p.report.Duration)
+	}
+
+	return p.report, err
+}
+
+// saveTarget 检查失败情况后把目标语言的译文写回并保存
+func (p *DocxProcessor) saveTarget(tg *target) (string, error) {
+	// 检查失败情况，严格模式下不保存
+	if p.logger != nil {
+		p.logger.LogStatistics(tg.report.Total(), tg.report.Translated, tg.report.Skipped)
+	}
+	if err := p.checkFailures(tg.report); err != nil {
+		if p.logger != nil {
+			p.logger.Error("%s: %v", tg.to, err)
+		}
+		return "", err
+	}
+
+	// 文档已写入其他语言的译文时重新加载原文
+	if p.dirty {
+		if err := p.reloadFile(); err != nil {
+			return "", err
+		}
+	}
+
+	p.writeChanges(tg)
+
+	outPath := ""
+	var err error
+	switch {
+	case p.targetWriter != nil:
+		var w io.Writer
+		if w, err = p.targetWriter(tg.to); err == nil {
+			err = p.f.Save(w)
+		}
+	case p.writer != nil:
+		err = p.f.Save(p.writer)
+	default:
+		outPath = path.Join(p.outputDir, fmt.Sprintf("%s_%s.docx", p.fileName, lang.LangNames[tg.to]))
+		if p.outputDir != "" {
+			err = os.MkdirAll(p.outputDir, os.ModePerm)
+		}
+		if err == nil {
+			err = p.f.SaveToFile(outPath)
+		}
+	}
+
+	if err == nil {
+		tg.report.Output = outPath
+		if tg.ckpt != nil {
+			_ = tg.ckpt.remove()
+		}
+		p.emit(Event{Type: EventSaved, Chunk: -1, Lang: tg.to, Path: outPath})
+	}
 
 	// 记录文件保存结果
 	if p.logger != nil {
 		p.logger.LogFileSave(err == nil, outPath, err)
+	}
+	return outPath, err
+}
 
-		// 记录翻译结束
-		p.logger.LogTranslationEnd(outPath, err == nil, time.Since(startTime))
+// openCheckpoint 按文档内容和语言打开目标语言的检查点
+func (p *DocxProcessor) openCheckpoint(tg *target) error {
+	var r io.Reader
+	if p.reader != nil {
+		r = io.NewSectionReader(p.reader, 0, p.readerSize)
+	} else {
+		f, err := os.Open(p.inputPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	// 换用其他翻译器或模型时不复用之前的译文
+	translator := ""
+	if p.process != nil {
+		translator = p.process.Name()
+	}
+	params := []string{p.fromLang, tg.to, translator, p.model}
+	if key := p.styleKey(tg); key != "" {
+		params = append(params, key)
+	}
+	hash, err := docHash(r, params...)
+	if err != nil {
+		return err
+	}
+
+	ckpt, err := openCheckpoint(p.checkpointDir, hash)
+	if err != nil {
+		return err
+	}
+	tg.ckpt = ckpt
+	if n := ckpt.len(); n > 0 && p.logger != nil {
+		p.logger.Info("从检查点恢复 %d 个已完成的分块(%s): %s", n, tg.to, ckpt.path)
+	}
+	return nil
+}
+
+// failureLimit 允许的失败分块占比，设置了失败占比时以占比为准，否则严格模式下不允许失败，小于 0 表示不限制
+func (p *DocxProcessor) failureLimit() float64 {
+	switch {
+	case p.hasFailRatio:
+		return p.maxFailRatio
+	case p.strict:
+		return 0
+	default:
+		return -1
+	}
+}
+
+// checkFailures 失败分块占比超过限制时返回错误
+func (p *DocxProcessor) checkFailures(r *Report) error {
+	if p.failLimit < 0 || r.Failed == 0 {
+		return nil
+	}
+
+	ratio := r.FailureRatio()
+	if ratio <= p.failLimit {
+		return nil
+	}
+	return fmt.Errorf("%w: %s: %d/%d chunks failed (%.2f%% > %.2f%%): %w",
+		ErrTooManyFailures, r.To, r.Failed, r.Failed+r.Translated,
+		ratio*100, p.failLimit*100, r.Err())
+}
+
+// logUsage 记录用量和费用汇总
+func (p *DocxProcessor) logUsage() {
+	for _, model := range p.report.Usage.Models() {
+		u := p.report.Usage[model]
+		p.logger.LogUsage(model, u.Requests, u.PromptTokens, u.CompletionTokens, p.prices[model].Cost(u))
+	}
+	total := p.report.Usage.Total()
+	p.logger.LogUsage("合计", total.Requests, total.PromptTokens, total.CompletionTokens, p.report.Cost)
+}
+
+// glossaryFor 分块中出现的术语，不区分大小写
+func (p *DocxProcessor) glossaryFor(para translate.Paragraph) map[string]string {
+	if len(p.glossary) == 0 {
+		return nil
 	}
 
-	return err
+	text := strings.ToLower(strings.Join(para, "\n"))
+	res := map[string]string{}
+	for term, tran := range p.glossary {
+		if strings.Contains(text, strings.ToLower(term)) {
+			res[term] = tran
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
 }
 
 func fillMap(src ...[]string) map[string]string {
@@ -428,3 +1023,9 @@ func combineMap(maps ...map[string]string) map[string]string {
 	}
 	return m
 }
+
+// outputName 输入文件去掉扩展名后的文件名，用于日志和输出文件名
+func outputName(input string) string {
+	base := filepath.Base(input)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -1,15 +1,23 @@
 package eden
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gou-jjjj/eden/lang"
 	"github.com/gou-jjjj/eden/logger"
 	"github.com/gou-jjjj/eden/prompt"
+	"github.com/gou-jjjj/eden/tokenizer"
 	"github.com/gou-jjjj/eden/translate"
 	"github.com/gou-jjjj/unioffice/document"
 )
@@ -41,7 +49,7 @@ func TestNewDocxProcessor(t *testing.T) {
 		WithLogger(newLogger),
 		WithMaxToken(100))
 
-	err = pr.Process()
+	_, err = pr.Process()
 	if err != nil {
 		t.Error(err)
 	}
@@ -103,7 +111,7 @@ func TestLogging(t *testing.T) {
 		WithMaxGo(1),
 		WithLogger(loggerInstance))
 
-	err = pr.Process()
+	_, err = pr.Process()
 	if err != nil {
 		t.Error(err)
 	}
@@ -139,3 +147,867 @@ func TestLogging(t *testing.T) {
 
 	t.Logf("日志文件内容预览:\n%s", logContent[:min(500, len(logContent))])
 }
+
+// testDocx 测试使用的示例文档
+const testDocx = "./file_examples/Docx4j_GettingStarted.docx"
+
+// newTestProcessor 用示例文档创建处理器，默认从英文翻译为中文
+// dir 为空时从内存读写并丢弃日志，否则输出到 dir 并在其中创建日志文件，opts 覆盖默认选项
+func newTestProcessor(t *testing.T, dir string, opts ...Opt) *DocxProcessor {
+	t.Helper()
+	base := []Opt{
+		WithLang(lang.EN, lang.ZH),
+		WithProcessFunc(&stubTran{}),
+		WithMaxToken(200),
+	}
+	if dir == "" {
+		src, err := os.ReadFile(testDocx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		base = append(base, WithBytes(src), WithWriter(io.Discard), WithLogWriter(io.Discard))
+	} else {
+		lg, err := logger.NewLogger(false, dir, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		base = append(base, WithInput(testDocx), WithOutput(dir), WithLogger(lg))
+	}
+	return NewDocxProcessor(append(base, opts...)...)
+}
+
+// stubTran 测试翻译器，默认原样返回原文，记录调用次数和所有请求
+type stubTran struct {
+	tag     bool                                         // 在译文前加上目标语言标记
+	fail    func(call int, req *translate.TranReq) error // 返回错误时本次调用失败，call 从 1 开始
+	summary string                                       // Summarize 返回的摘要
+
+	calls atomic.Int32
+	mu    sync.Mutex
+	reqs  []translate.TranReq
+}
+
+func (s *stubTran) T(req *translate.TranReq) (translate.Paragraph, error) {
+	call := int(s.calls.Add(1))
+	s.mu.Lock()
+	s.reqs = append(s.reqs, *req)
+	s.mu.Unlock()
+
+	if s.fail != nil {
+		if err := s.fail(call, req); err != nil {
+			return nil, err
+		}
+	}
+	if !s.tag {
+		return req.Paras, nil
+	}
+	res := make(translate.Paragraph, len(req.Paras))
+	for i, p := range req.Paras {
+		res[i] = "<" + req.To + ">" + p
+	}
+	return res, nil
+}
+
+func (s *stubTran) Name() string {
+	return "stub"
+}
+
+func (s *stubTran) Summarize(req *translate.SummaryReq) (string, error) {
+	req.AddUsage("summary", translate.Usage{Requests: 1})
+	return s.summary, nil
+}
+
+// requests 按调用顺序返回所有请求
+func (s *stubTran) requests() []translate.TranReq {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]translate.TranReq{}, s.reqs...)
+}
+
+// failEveryOther 每隔一次调用失败一次
+func failEveryOther(call int, _ *translate.TranReq) error {
+	if call%2 == 0 {
+		return errors.New("authentication failed")
+	}
+	return nil
+}
+
+// failOnce 每个分块第一次调用失败
+func failOnce() func(int, *translate.TranReq) error {
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	return func(_ int, req *translate.TranReq) error {
+		mu.Lock()
+		defer mu.Unlock()
+		key := strings.Join(req.Paras, translate.Seq)
+		if !seen[key] {
+			seen[key] = true
+			return errors.New("service unavailable")
+		}
+		return nil
+	}
+}
+
+func TestExtractText_TokenBudget(t *testing.T) {
+	const budget = 50
+	tk := tokenizer.RuneTokenizer{}
+	pr := newTestProcessor(t, "", WithLang(lang.ZH), WithTokenizer(tk), WithMaxToken(budget))
+
+	if err := pr.LoadFile(); err != nil {
+		t.Fatal(err)
+	}
+	defer pr.closeFunc()
+	if err := pr.ExtractText(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pr.paraSet) < 2 {
+		t.Fatalf("Expected document to be split into several chunks, got %d", len(pr.paraSet))
+	}
+	seq := tk.Count(translate.Seq)
+	for i, para := range pr.paraSet {
+		tokens := 0
+		for _, s := range para {
+			tokens += tk.Count(s) + seq
+		}
+		if tokens > budget && len(para) > 1 {
+			t.Errorf("Chunk %d has %d tokens, over budget %d", i, tokens, budget)
+		}
+	}
+}
+
+func TestExtractText_TrailingEmptyParagraph(t *testing.T) {
+	doc := document.New()
+	doc.AddParagraph().AddRun().AddText("The first paragraph.")
+	doc.AddParagraph().AddRun().AddText("The last paragraph with text.")
+	doc.AddParagraph()
+	src := &bytes.Buffer{}
+	if err := doc.Save(src); err != nil {
+		t.Fatal(err)
+	}
+
+	// 最后一个段落为空时最后的分块不能丢失
+	chunks, err := newTestProcessor(t, "", WithBytes(src.Bytes())).Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || len(chunks[0]) != 2 || chunks[0][1] != "The last paragraph with text." {
+		t.Errorf("Unexpected chunks %q", chunks)
+	}
+}
+
+func TestChunkBudget_Overhead(t *testing.T) {
+	tk := tokenizer.RuneTokenizer{}
+	glossary := map[string]string{"Docx4j": "Docx4j", "document": "文档", "package": "包"}
+	newProcessor := func(opts ...Opt) *DocxProcessor {
+		return newTestProcessor(t, "", append([]Opt{
+			WithLang(lang.ZH),
+			WithMaxToken(0),
+			WithModel("gpt-4"),
+			WithTokenizer(tk)}, opts...)...)
+	}
+
+	plain := newProcessor()
+	full := newProcessor(
+		WithGlossary(glossary),
+		WithSummarizer(&stubTran{summary: strings.Repeat("summary ", DefaultSummaryTokens)}),
+		WithContextWindow(3),
+		WithDomain(translate.DomainLegal))
+
+	base, overhead := plain.overheadTokens(plain.targets[0], -1), full.overheadTokens(full.targets[0], -1)
+	if overhead-base < DefaultSummaryTokens+DefaultContextTokens {
+		t.Errorf("Overhead %d does not reserve summary and context over %d", overhead, base)
+	}
+	want := tokenizer.ChunkBudget(tokenizer.LimitFor("gpt-4"), overhead, tokenizer.DefaultExpansion)
+	if got := full.chunkBudget(); got != want || got >= plain.chunkBudget() {
+		t.Errorf("chunkBudget() = %d, want %d below %d", got, want, plain.chunkBudget())
+	}
+
+	// 预估与分块预算使用同样的请求开销
+	plainEst, err := plain.Estimate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullEst, err := full.Estimate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fullEst.PromptTokens-plainEst.PromptTokens < fullEst.Requests*DefaultSummaryTokens {
+		t.Errorf("Estimate %d does not include summary over %d", fullEst.PromptTokens, plainEst.PromptTokens)
+	}
+
+	// 摘要按上限截断
+	full.summarize()
+	if n := tk.Count(full.summary); n == 0 || n > DefaultSummaryTokens {
+		t.Errorf("Summary has %d tokens, want at most %d", n, DefaultSummaryTokens)
+	}
+}
+
+func TestProcess_Report(t *testing.T) {
+	pr := newTestProcessor(t, t.TempDir(),
+		WithProcessFunc(translate.NewMockTran()),
+		WithMaxGo(4),
+		WithPriceTable(translate.PriceTable{"Mock": {Prompt: 1, Completion: 1}}))
+	logPath := pr.logger.GetLogFilePath()
+
+	report, err := pr.Process()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Output == "" {
+		t.Fatal("Expected output path in report")
+	}
+	if _, err := os.Stat(report.Output); err != nil {
+		t.Errorf("Output file missing: %v", err)
+	}
+	if len(report.Chunks) == 0 {
+		t.Fatal("Expected chunk results")
+	}
+	if got := report.Usage.Total().Requests; got != len(report.Chunks) {
+		t.Errorf("Expected %d requests, got %d", len(report.Chunks), got)
+	}
+	if report.From != lang.EN || report.To != lang.ZH || report.Translator != "Mock" {
+		t.Errorf("Unexpected report header %+v", report)
+	}
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "用量[合计]") {
+		t.Error("Expected usage summary in log")
+	}
+}
+
+func TestProcess_DryRun(t *testing.T) {
+	tran := &stubTran{}
+	pr := newTestProcessor(t, t.TempDir(),
+		WithProcessFunc(tran),
+		WithMaxGo(2),
+		WithModel("gpt-4o"),
+		WithTokenizer(tokenizer.EstimateTokenizer{}),
+		WithRequestLatency(time.Second),
+		WithDryRun())
+
+	report, err := pr.Process()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	est := report.Estimate
+	if est == nil {
+		t.Fatal("Expected estimate in dry-run report")
+	}
+	if tran.calls.Load() != 0 {
+		t.Errorf("Dry run must not call the translator, got %d calls", tran.calls.Load())
+	}
+	if report.Output != "" {
+		t.Errorf("Dry run must not save output, got %s", report.Output)
+	}
+	if est.Requests == 0 || est.PromptTokens == 0 || est.CompletionTokens == 0 || est.Cost <= 0 {
+		t.Errorf("Unexpected estimate %+v", est)
+	}
+	if want := time.Duration(est.Requests) * time.Second / 2; est.Duration != want {
+		t.Errorf("Estimated duration %v, want %v", est.Duration, want)
+	}
+}
+
+func TestProcess_NoOutputFiles(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	opts := []Opt{
+		WithInput(testDocx),
+		WithOutput(out),
+		WithLang(lang.ZH),
+		WithProcessFunc(&stubTran{}),
+		WithMaxToken(200),
+	}
+
+	// 只提取文本和试运行都不创建输出目录和日志文件
+	if _, err := NewDocxProcessor(opts...).Extract(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewDocxProcessor(append(opts, WithDryRun())...).Process(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("Expected no output directory, got %v", err)
+	}
+
+	report, err := NewDocxProcessor(opts...).Process()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{report.Output, filepath.Join(out, "Docx4j_GettingStarted_log.txt")} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("Expected %s: %v", name, err)
+		}
+	}
+}
+
+func TestProcess_FailureReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Opt
+		wantErr bool
+	}{
+		{name: "lenient"},
+		{name: "strict", opts: []Opt{WithStrict()}, wantErr: true},
+		{name: "ratio exceeded", opts: []Opt{WithMaxFailureRatio(0.1)}, wantErr: true},
+		{name: "ratio allowed", opts: []Opt{WithMaxFailureRatio(0.9)}},
+		// 失败占比优先于严格模式，与选项顺序无关
+		{name: "strict then ratio allowed", opts: []Opt{WithStrict(), WithMaxFailureRatio(0.9)}},
+		{name: "ratio allowed then strict", opts: []Opt{WithMaxFailureRatio(0.9), WithStrict()}},
+		{name: "strict then ratio exceeded", opts: []Opt{WithStrict(), WithMaxFailureRatio(0.1)}, wantErr: true},
+		{name: "ratio exceeded then strict", opts: []Opt{WithMaxFailureRatio(0.1), WithStrict()}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Opt{
+				WithProcessFunc(&stubTran{fail: failEveryOther}),
+				WithMaxGo(1),
+			}, tt.opts...)
+
+			report, err := newTestProcessor(t, t.TempDir(), opts...).Process()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrTooManyFailures) {
+				t.Errorf("Expected ErrTooManyFailures, got %v", err)
+			}
+
+			if report.Failed == 0 || report.Translated == 0 {
+				t.Fatalf("Expected both failed and translated chunks, got %+v", report)
+			}
+			failed := report.ChunksByStatus(ChunkFailed)
+			if len(failed) != report.Failed || failed[0].Error == "" || len(failed[0].Segments) == 0 {
+				t.Errorf("Expected failed chunks with errors and segments, got %+v", failed)
+			}
+			if !errors.Is(report.Err(), failed[0].Err) {
+				t.Error("Expected report error to include chunk errors")
+			}
+
+			saved := report.Output != ""
+			if saved == tt.wantErr {
+				t.Errorf("Output saved = %v, want %v", saved, !tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProcess_CheckpointResume(t *testing.T) {
+	dir := t.TempDir()
+	ckptDir := filepath.Join(dir, "ckpt")
+	newProcessor := func(tran translate.Translate) *DocxProcessor {
+		return newTestProcessor(t, dir,
+			WithProcessFunc(tran),
+			WithMaxGo(1),
+			WithCheckpoint(ckptDir),
+			WithStrict())
+	}
+
+	// 第一次运行部分失败，严格模式下不保存，检查点保留
+	first, err := newProcessor(&stubTran{fail: failEveryOther}).Process()
+	if !errors.Is(err, ErrTooManyFailures) {
+		t.Fatalf("Expected first run to fail, got %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(ckptDir, "*.ckpt.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one checkpoint file, got %v", files)
+	}
+
+	// 换用其他翻译器时不复用之前的译文
+	other, err := newProcessor(translate.NewMockTran()).Process()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range other.Chunks {
+		if c.Resumed {
+			t.Fatalf("Expected no chunk to be resumed with another translator, got %+v", c)
+		}
+	}
+
+	// 第二次运行只翻译失败的分块
+	tran := &stubTran{}
+	second, err := newProcessor(tran).Process()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int(tran.calls.Load()) != first.Failed {
+		t.Errorf("Expected only %d failed chunks to be retried, got %d calls", first.Failed, tran.calls.Load())
+	}
+
+	resumed := 0
+	for _, c := range second.Chunks {
+		if c.Resumed {
+			resumed++
+		}
+	}
+	if resumed != first.Translated {
+		t.Errorf("Expected %d resumed chunks, got %d", first.Translated, resumed)
+	}
+
+	// 完成后删除检查点
+	if _, err := os.Stat(files[0]); !os.IsNotExist(err) {
+		t.Errorf("Expected checkpoint to be removed after success, got %v", err)
+	}
+
+	// 删除后再次关闭不会出错
+	ckpt, err := openCheckpoint(ckptDir, "closed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ckpt.remove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ckpt.close(); err != nil {
+		t.Errorf("Expected second close to succeed, got %v", err)
+	}
+	if err := ckpt.save("0", translate.Paragraph{"a"}, translate.Paragraph{"b"}); err == nil {
+		t.Error("Expected save after close to fail")
+	}
+}
+
+func TestProcess_Events(t *testing.T) {
+	retry := translate.RetryConfig{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, BackoffFactor: 1}
+	events := make([]Event, 0)
+	pr := newTestProcessor(t, t.TempDir(),
+		WithProcessFunc(translate.NewRetry(&stubTran{fail: failOnce()}, retry)),
+		WithMaxGo(4),
+		WithEventHandler(func(e Event) {
+			events = append(events, e)
+		}))
+
+	report, err := pr.Process()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := map[EventType]int{}
+	for _, e := range events {
+		count[e.Type]++
+	}
+	if events[0].Type != EventLoaded || events[len(events)-1].Type != EventDone {
+		t.Errorf("Unexpected event order: first %s, last %s", events[0].Type, events[len(events)-1].Type)
+	}
+	if count[EventExtracted] != 1 || count[EventWritten] != 1 || count[EventSaved] != 1 {
+		t.Errorf("Unexpected stage events %v", count)
+	}
+	if count[EventChunkSucceeded] != report.Translated || count[EventChunkRetried] != report.Translated {
+		t.Errorf("Expected one success and one retry per translated chunk, got %v", count)
+	}
+	if count[EventChunkStarted] != report.Translated || count[EventChunkSkipped] != report.Skipped {
+		t.Errorf("Unexpected chunk events %v", count)
+	}
+
+	last := events[len(events)-1]
+	if last.Total != report.Total() || last.Done != last.Total || last.ETA != 0 {
+		t.Errorf("Unexpected final counters %+v", last)
+	}
+}
+
+func TestProcess_InMemory(t *testing.T) {
+	out := &bytes.Buffer{}
+	logs := &bytes.Buffer{}
+	pr := newTestProcessor(t, "",
+		WithName("upload"),
+		WithWriter(out),
+		WithLogWriter(logs))
+
+	report, err := pr.Process()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Input != "upload" || report.Output != "" {
+		t.Errorf("Unexpected report paths %q %q", report.Input, report.Output)
+	}
+
+	doc, err := document.Read(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("Output is not a valid docx: %v", err)
+	}
+	defer doc.Close()
+	if len(doc.Paragraphs()) == 0 {
+		t.Error("Expected paragraphs in output document")
+	}
+
+	if !strings.Contains(logs.String(), "翻译任务完成") {
+		t.Errorf("Expected logs in caller's writer, got %q", logs.String())
+	}
+}
+
+func TestProcess_MultiTarget(t *testing.T) {
+	tran := &stubTran{tag: true}
+	outs := map[string]*bytes.Buffer{}
+	var mu sync.Mutex
+	var langs []string
+	pr := newTestProcessor(t, "",
+		WithName("multi"),
+		WithTargetWriter(func(to string) (io.Writer, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			outs[to] = &bytes.Buffer{}
+			return outs[to], nil
+		}),
+		WithLang(lang.EN, lang.JA, lang.RU),
+		WithProcessFunc(tran),
+		WithMaxGo(4),
+		WithEventHandler(func(e Event) {
+			if e.Type == EventSaved {
+				langs = append(langs, e.Lang)
+			}
+		}))
+
+	report, err := pr.Process()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Targets) != 2 {
+		t.Fatalf("Expected 2 target reports, got %d", len(report.Targets))
+	}
+	chunks := len(pr.paraSet)
+	if got := int(tran.calls.Load()); got != report.Translated || report.Translated != 2*chunks {
+		t.Errorf("Expected %d calls, got %d (translated %d)", 2*chunks, got, report.Translated)
+	}
+	if len(langs) != 2 || langs[0] != lang.JA || langs[1] != lang.RU {
+		t.Errorf("Unexpected saved languages %v", langs)
+	}
+
+	for _, to := range []string{lang.JA, lang.RU} {
+		out := outs[to]
+		if out == nil {
+			t.Fatalf("No output for %s", to)
+		}
+		doc, err := document.Read(bytes.NewReader(out.Bytes()), int64(out.Len()))
+		if err != nil {
+			t.Fatalf("Output for %s is not a valid docx: %v", to, err)
+		}
+
+		text := ""
+		for _, para := range doc.Paragraphs() {
+			for _, r := range para.Runs() {
+				text += r.Text()
+			}
+		}
+		_ = doc.Close()
+
+		// 每个输出只能包含自己语言的译文
+		for _, other := range []string{lang.JA, lang.RU} {
+			has := strings.Contains(text, "<"+other+">")
+			if has != (other == to) {
+				t.Errorf("Output for %s contains %s translation: %v", to, other, has)
+			}
+		}
+	}
+}
+
+func TestWithLang(t *testing.T) {
+	pr := NewDocxProcessor(WithLang(lang.ZH), WithWriter(io.Discard))
+	if pr.fromLang != lang.All || len(pr.toLangs) != 1 || pr.toLang != lang.ZH {
+		t.Errorf("Unexpected langs %q %v", pr.fromLang, pr.toLangs)
+	}
+
+	pr = NewDocxProcessor(WithLang(lang.ZH, lang.EN, lang.JA), WithWriter(io.Discard))
+	if pr.fromLang != lang.ZH || len(pr.targets) != 2 || pr.toLang != lang.EN {
+		t.Errorf("Unexpected langs %q %v", pr.fromLang, pr.toLangs)
+	}
+
+	// 多个目标语言不能写入同一个 io.Writer
+	if _, err := pr.Process(); err == nil {
+		t.Error("Expected error for multiple targets with a single writer")
+	}
+
+	// 语言检查器只能判断一种语言，多个目标语言时报错而不是忽略
+	pr = newTestProcessor(t, "",
+		WithLang(lang.EN, lang.ZH, lang.JA),
+		WithTargetWriter(func(string) (io.Writer, error) { return io.Discard, nil }),
+		WithLangChecker(lang.LangMapChecks[lang.ZH]))
+	if _, err := pr.Process(); err == nil || !strings.Contains(err.Error(), "WithLangChecker") {
+		t.Errorf("Expected error for a checker with multiple targets, got %v", err)
+	}
+	if _, err := pr.Extract(); err == nil {
+		t.Error("Expected Extract to reject a checker with multiple targets")
+	}
+}
+
+func TestGlossaryFor(t *testing.T) {
+	pr := NewDocxProcessor(
+		WithWriter(io.Discard),
+		WithGlossary(map[string]string{"Docx4j": "Docx4j", "eden": "伊甸", "absent": "缺席"}))
+
+	got := pr.glossaryFor(translate.Paragraph{"Getting started with docx4j", "EDEN rocks"})
+	if len(got) != 2 || got["eden"] != "伊甸" || got["Docx4j"] != "Docx4j" {
+		t.Errorf("Unexpected glossary %v", got)
+	}
+	if got := pr.glossaryFor(translate.Paragraph{"nothing here"}); got != nil {
+		t.Errorf("Expected nil glossary, got %v", got)
+	}
+}
+
+// TestProcess_Requests 检查发送给翻译器的请求，每个用例记录所有请求后检查
+func TestProcess_Requests(t *testing.T) {
+	temp := 0.3
+	tests := []struct {
+		name  string
+		tran  *stubTran
+		opts  []Opt
+		check func(t *testing.T, pr *DocxProcessor, report *Report, reqs []translate.TranReq)
+	}{
+		{
+			name: "context",
+			tran: &stubTran{tag: true, summary: "A getting started guide."},
+			opts: []Opt{
+				WithLang(lang.EN, lang.JA),
+				WithContextWindow(2),
+				WithMaxGo(1),
+				WithMaxToken(100),
+			},
+			check: checkContextRequests,
+		},
+		{
+			name: "style",
+			tran: &stubTran{},
+			opts: []Opt{
+				WithTargetWriter(func(to string) (io.Writer, error) {
+					return io.Discard, nil
+				}),
+				WithLang(lang.EN, lang.ZH, lang.JA),
+				WithDomain(translate.DomainLegal),
+				WithTone("neutral"),
+				WithFormality(translate.FormalityFormal),
+				WithAudience("lawyers"),
+				WithLocale("zh_tw", "en-GB"),
+			},
+			check: checkStyleRequests,
+		},
+		{
+			name: "metadata",
+			tran: &stubTran{},
+			opts: []Opt{
+				WithName("guide"),
+				WithJobID("job-1"),
+				WithLabels(map[string]string{"team": "docs"}),
+				WithRequestOptions(translate.ReqOptions{Temperature: &temp}),
+				WithMaxGo(1),
+			},
+			check: func(t *testing.T, pr *DocxProcessor, report *Report, reqs []translate.TranReq) {
+				checkMetaRequests(t, pr, reqs, temp)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Opt{WithProcessFunc(tt.tran)}, tt.opts...)
+			if tt.tran.summary != "" {
+				opts = append(opts, WithSummarizer(tt.tran))
+			}
+			pr := newTestProcessor(t, "", opts...)
+			report, err := pr.Process()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, pr, report, tt.tran.requests())
+		})
+	}
+}
+
+// checkContextRequests 每个请求带有摘要和之前已翻译的片段
+func checkContextRequests(t *testing.T, pr *DocxProcessor, report *Report, reqs []translate.TranReq) {
+	if report.Summary != "A getting started guide." || report.Usage["summary"].Requests != 1 {
+		t.Errorf("Unexpected summary %q, usage %v", report.Summary, report.Usage)
+	}
+	if len(pr.paraSet) < 2 {
+		t.Fatalf("Expected several chunks, got %d", len(pr.paraSet))
+	}
+
+	contexts := map[string]*translate.DocContext{}
+	for _, req := range reqs {
+		contexts[strings.Join(req.Paras, translate.Seq)] = req.Context
+	}
+	requested := 0
+	for i, para := range pr.paraSet {
+		ctx, ok := contexts[strings.Join(para, translate.Seq)]
+		if !ok {
+			// 已经是目标语言的分块没有请求
+			continue
+		}
+		requested++
+		if ctx == nil || ctx.Summary != report.Summary {
+			t.Fatalf("chunk %d: unexpected context %+v", i, ctx)
+		}
+		if i == 0 {
+			if len(ctx.Previous) != 0 {
+				t.Errorf("chunk 0 has previous segments %+v", ctx.Previous)
+			}
+			continue
+		}
+
+		// 并发数为 1 时之前的片段都已翻译
+		prev := pr.paraSet[i-1]
+		last := ctx.Previous[len(ctx.Previous)-1]
+		if len(ctx.Previous) > 2 || last.Source != prev[len(prev)-1] || last.Target != "<"+lang.JA+">"+last.Source {
+			t.Errorf("chunk %d: unexpected previous segments %+v", i, ctx.Previous)
+		}
+	}
+	if requested < 2 {
+		t.Errorf("Expected several requests, got %d", requested)
+	}
+}
+
+// checkStyleRequests 翻译风格按目标语言发送
+func checkStyleRequests(t *testing.T, _ *DocxProcessor, _ *Report, reqs []translate.TranReq) {
+	byLang := map[string]translate.TranReq{}
+	for _, req := range reqs {
+		byLang[req.To] = req
+	}
+	zh, ja := byLang[lang.ZH], byLang[lang.JA]
+	if zh.Domain != translate.DomainLegal || zh.Tone != "neutral" || zh.Formality != translate.FormalityFormal || zh.Audience != "lawyers" {
+		t.Errorf("Unexpected style %+v", zh)
+	}
+	if zh.Locale != "zh-TW" || ja.Locale != "" || ja.Domain != translate.DomainLegal {
+		t.Errorf("Unexpected locales %q %q", zh.Locale, ja.Locale)
+	}
+
+	for in, want := range map[string]string{"zh_tw": "zh-TW", "EN-gb": "en-GB", "zh-hant-hk": "zh-Hant-HK", "pt": "pt"} {
+		if got := NormalizeLocale(in); got != want {
+			t.Errorf("NormalizeLocale(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// checkMetaRequests 每个请求带有任务信息、请求选项和相互引用的片段信息
+func checkMetaRequests(t *testing.T, pr *DocxProcessor, reqs []translate.TranReq, temp float64) {
+	if len(reqs) != len(pr.paraSet) {
+		t.Fatalf("Expected %d requests, got %d", len(pr.paraSet), len(reqs))
+	}
+
+	ids := map[string]bool{}
+	headings, cells := 0, 0
+	var last translate.Segment
+	lastText := ""
+	for i, req := range reqs {
+		if req.Meta.JobID != "job-1" || req.Meta.Document != "guide" || req.Meta.Chunk != i ||
+			req.Meta.Chunks != len(pr.paraSet) || req.Meta.Labels["team"] != "docs" {
+			t.Errorf("Unexpected meta %+v", req.Meta)
+		}
+		if req.Options.Temperature == nil || *req.Options.Temperature != temp {
+			t.Errorf("Unexpected options %+v", req.Options)
+		}
+		if len(req.Segments) != len(req.Paras) {
+			t.Fatalf("Chunk %d has %d segments for %d paras", i, len(req.Segments), len(req.Paras))
+		}
+		for j, seg := range req.Segments {
+			if ids[seg.ID] {
+				t.Errorf("Duplicate segment ID %s", seg.ID)
+			}
+			ids[seg.ID] = true
+			// 相邻片段互相引用
+			if last.ID != "" && (seg.Prev != lastText || last.Next != req.Paras[j]) {
+				t.Errorf("Segment %s is not linked to %s", seg.ID, last.ID)
+			}
+			last, lastText = seg, req.Paras[j]
+			if seg.Heading() {
+				headings++
+			}
+			if seg.TableCell {
+				cells++
+			}
+		}
+	}
+	if headings == 0 || cells == 0 {
+		t.Errorf("Expected headings and table cells, got %d and %d", headings, cells)
+	}
+}
+
+func TestProcess_Detect(t *testing.T) {
+	doc := document.New()
+	for _, text := range []string{
+		"Le rapport annuel sera publié le mois prochain.",
+		"Veuillez vous assurer que toutes les informations sont exactes et à jour.",
+		"Der Jahresbericht wird nächsten Monat veröffentlicht.",
+		"OK",
+	} {
+		doc.AddParagraph().AddRun().AddText(text)
+	}
+	src := &bytes.Buffer{}
+	if err := doc.Save(src); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Opt
+		detected string // 检测到的文档语言，为空时不检测
+	}{
+		{name: "detect", opts: []Opt{WithLang(lang.All, lang.ZH)}, detected: lang.FR},
+		{name: "source given", opts: []Opt{WithLang(lang.EN, lang.ZH)}},
+		{name: "detector disabled", opts: []Opt{WithLang(lang.All, lang.ZH), WithDetector(nil)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tran := &stubTran{}
+			opts := append([]Opt{
+				WithBytes(src.Bytes()),
+				WithProcessFunc(tran),
+				WithMaxGo(1),
+				WithMaxToken(1), // 每个片段一个分块
+			}, tt.opts...)
+			report, err := newTestProcessor(t, "", opts...).Process()
+			if err != nil {
+				t.Fatal(err)
+			}
+			reqs := tran.requests()
+
+			// 指定源语言或关闭检测时不检测
+			if tt.detected == "" {
+				if report.Detected != nil || reqs[0].Segments[0].Lang != "" {
+					t.Errorf("Unexpected detection %+v", report.Detected)
+				}
+				return
+			}
+
+			if report.Detected == nil || report.Detected.Lang != tt.detected {
+				t.Fatalf("Unexpected document language %+v", report.Detected)
+			}
+			froms := map[string]string{}
+			for _, req := range reqs {
+				for i, para := range req.Paras {
+					froms[para] = req.From
+					if seg := req.Segments[i]; para == "OK" && seg.Lang != "" {
+						t.Errorf("Expected no language for short text, got %+v", seg)
+					}
+				}
+			}
+			// 分块语言不确定时使用文档的语言
+			want := map[string]string{
+				"Le rapport annuel sera publié le mois prochain.":       lang.FR,
+				"Der Jahresbericht wird nächsten Monat veröffentlicht.": lang.DE,
+				"OK": lang.FR,
+			}
+			for para, from := range want {
+				if froms[para] != from {
+					t.Errorf("From for %q = %q, want %q", para, froms[para], from)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputName(t *testing.T) {
+	cases := map[string]string{
+		"a.docx":            "a",
+		"dir/a.b.docx":      "a.b",
+		"v1.2 report.docx":  "v1.2 report",
+		"noext":             "noext",
+		"dir.d/report.docx": "report",
+	}
+	for in, want := range cases {
+		if got := outputName(in); got != want {
+			t.Errorf("outputName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package eden
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gou-jjjj/eden/translate"
+)
+
+const (
+	// DefaultRequestLatency 单次请求的基础耗时
+	DefaultRequestLatency = 2 * time.Second
+	// DefaultOutputRate 模型输出速度，单位 token/秒
+	DefaultOutputRate = 50.0
+)
+
+// Estimate 翻译前的预估结果
+type Estimate struct {
+	Model            string        `json:"model"`
+	Tokenizer        string        `json:"tokenizer"`
+	Segments         int           `json:"segments"`          // 需要翻译的文本块数，多个目标语言时按语言累加
+	Chunks           int           `json:"chunks"`            // 分块数
+	Requests         int           `json:"requests"`          // 预计请求数，不含重试
+	PromptTokens     int           `json:"prompt_tokens"`     // 预计输入 token 数，含提示词
+	CompletionTokens int           `json:"completion_tokens"` // 预计输出 token 数
+	Cost             float64       `json:"cost"`
+	Duration         time.Duration `json:"duration"`
+}
+
+// Estimate 加载并提取文档，估算翻译所需的请求数、token、费用和耗时，不会调用翻译器
+func (p *DocxProcessor) Estimate() (*Estimate, error) {
+	if _, err := p.Extract(); err != nil {
+		return nil, err
+	}
+	return p.estimate(), nil
+}
+
+// Extract 加载并提取文档，返回需要翻译的分块，不会调用翻译器
+func (p *DocxProcessor) Extract() ([]translate.Paragraph, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	if p.f == nil {
+		if err := p.LoadFile(); err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = p.closeFunc()
+			p.f = nil
+			p.closeFunc = nil
+		}()
+	}
+
+	if err := p.ExtractText(); err != nil {
+		return nil, err
+	}
+	return p.paraSet, nil
+}
+
+// estimate 根据已提取的分块计算预估结果，请求开销与分块预算按同样的方式计算
+func (p *DocxProcessor) estimate() *Estimate {
+	est := &Estimate{
+		Model:     p.model,
+		Tokenizer: p.tokenizer.Name(),
+		Chunks:    len(p.paraSet),
+	}
+
+	for _, tg := range p.targets {
+		for i, para := range p.paraSet {
+			if tg.skipChunk(para) {
+				continue
+			}
+
+			chunkTokens := p.tokenizer.Count(strings.Join(para, translate.Seq))
+			completion := int(float64(chunkTokens) * p.expansion)
+
+			est.Requests++
+			est.Segments += len(para)
+			est.PromptTokens += p.overheadTokens(tg, i) + chunkTokens
+			est.CompletionTokens += completion
+
+			latency := p.latency
+			if latency <= 0 {
+				latency = DefaultRequestLatency + time.Duration(float64(completion)/DefaultOutputRate*float64(time.Second))
+			}
+			est.Duration += latency
+		}
+	}
+
+	// 请求按最大并发数并行执行
+	est.Duration /= time.Duration(p.maxGo)
+	est.Cost = p.prices[p.model].Cost(translate.Usage{
+		PromptTokens:     est.PromptTokens,
+		CompletionTokens: est.CompletionTokens,
+	})
+	return est
+}
@@ -0,0 +1,106 @@
+package eden
+
+import (
+	"time"
+)
+
+// EventType 进度事件类型
+type EventType string
+
+const (
+	EventLoaded         EventType = "loaded"          // 文件加载完成
+	EventExtracted      EventType = "extracted"       // 文本提取完成，Total 为分块数
+	EventChunkStarted   EventType = "chunk_started"   // 分块开始翻译
+	EventChunkSucceeded EventType = "chunk_succeeded" // 分块翻译成功
+	EventChunkFailed    EventType = "chunk_failed"    // 分块翻译失败
+	EventChunkRetried   EventType = "chunk_retried"   // 分块重试，Attempt 为第几次重试
+	EventChunkSkipped   EventType = "chunk_skipped"   // 分块无需翻译或已从检查点恢复
+	EventWritten        EventType = "written"         // 译文写回文档完成
+	EventSaved          EventType = "saved"           // 文件保存完成
+	EventDone           EventType = "done"            // 任务结束，失败时 Err 不为空
+)
+
+// Event 进度事件
+type Event struct {
+	Type    EventType     `json:"type"`
+	Time    time.Time     `json:"time"`
+	Chunk   int           `json:"chunk"`             // 分块序号，-1 表示与分块无关
+	Lang    string        `json:"lang,omitempty"`    // 目标语言，与目标语言无关时为空
+	Attempt int           `json:"attempt,omitempty"` // 重试次数
+	Total   int           `json:"total"`             // 分块总数，多个目标语言时为分块数乘以语言数
+	Done    int           `json:"done"`              // 已完成的分块数，包括失败和跳过
+	Failed  int           `json:"failed"`            // 失败的分块数
+	Elapsed time.Duration `json:"elapsed"`           // 任务已运行时间
+	ETA     time.Duration `json:"eta"`               // 预计剩余时间
+	Path    string        `json:"path,omitempty"`    // 加载或保存的文件路径
+	Err     error         `json:"-"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// EventHandler 进度事件回调，回调按顺序串行调用，不需要并发安全
+type EventHandler func(Event)
+
+// WithEventHandler 添加进度事件回调
+func WithEventHandler(h EventHandler) Opt {
+	return func(p *DocxProcessor) {
+		if h != nil {
+			p.handlers = append(p.handlers, h)
+		}
+	}
+}
+
+// WithEventChan 把进度事件发送到 ch，调用方需要及时读取，否则会阻塞翻译
+func WithEventChan(ch chan<- Event) Opt {
+	return WithEventHandler(func(e Event) {
+		ch <- e
+	})
+}
+
+// progress 进度计数
+type progress struct {
+	total     int
+	done      int
+	failed    int
+	requested int // 实际请求过翻译器的分块数，用于估算剩余时间
+	start     time.Time
+	tranStart time.Time
+}
+
+// emit 填充计数并发送事件
+func (p *DocxProcessor) emit(e Event) {
+	if len(p.handlers) == 0 {
+		return
+	}
+
+	p.evMu.Lock()
+	defer p.evMu.Unlock()
+
+	now := time.Now()
+	e.Time = now
+	if e.Type == EventChunkSucceeded || e.Type == EventChunkFailed || e.Type == EventChunkSkipped {
+		p.progress.done++
+		if e.Type == EventChunkFailed {
+			p.progress.failed++
+		}
+		if e.Type != EventChunkSkipped {
+			p.progress.requested++
+		}
+	}
+	e.Total = p.progress.total
+	e.Done = p.progress.done
+	e.Failed = p.progress.failed
+	if !p.progress.start.IsZero() {
+		e.Elapsed = now.Sub(p.progress.start)
+	}
+	if p.progress.requested > 0 && p.progress.done < p.progress.total {
+		perChunk := now.Sub(p.progress.tranStart) / time.Duration(p.progress.requested)
+		e.ETA = perChunk * time.Duration(p.progress.total-p.progress.done)
+	}
+	if e.Err != nil {
+		e.Error = e.Err.Error()
+	}
+
+	for _, h := range p.handlers {
+		h(e)
+	}
+}
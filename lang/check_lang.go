@@ -1,7 +1,9 @@
 package lang
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 	"unicode"
 )
 
@@ -16,6 +18,13 @@ const (
 	RU = "Russian"  // 俄文
 	AR = "Arabic"   // 阿拉伯文
 	EL = "Greek"    // 希腊文
+
+	FR = "French"     // 法文
+	DE = "German"     // 德文
+	ES = "Spanish"    // 西班牙文
+	IT = "Italian"    // 意大利文
+	PT = "Portuguese" // 葡萄牙文
+	NL = "Dutch"      // 荷兰文
 )
 
 var LangNames = map[string]string{
@@ -26,6 +35,46 @@ var LangNames = map[string]string{
 	RU: "俄文",
 	AR: "阿拉伯文",
 	EL: "希腊文",
+	FR: "法文",
+	DE: "德文",
+	ES: "西班牙文",
+	IT: "意大利文",
+	PT: "葡萄牙文",
+	NL: "荷兰文",
+}
+
+// LangCodes 语言代码到语言的映射
+var LangCodes = map[string]string{
+	"zh": ZH,
+	"en": EN,
+	"ja": JA,
+	"ko": KO,
+	"ru": RU,
+	"ar": AR,
+	"el": EL,
+	"fr": FR,
+	"de": DE,
+	"es": ES,
+	"it": IT,
+	"pt": PT,
+	"nl": NL,
+}
+
+// Lookup 按语言代码或语言名查找语言，不区分大小写，all 表示所有语言
+func Lookup(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if strings.EqualFold(name, "all") || strings.EqualFold(name, All) {
+		return All, nil
+	}
+	if l, ok := LangCodes[strings.ToLower(name)]; ok {
+		return l, nil
+	}
+	for l := range LangNames {
+		if strings.EqualFold(name, l) || name == LangNames[l] {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unknown language %q", name)
 }
 
 // LanguageChecker 语言检查器接口
@@ -73,13 +122,41 @@ func (c ChineseChecker) Name() string {
 type EnglishChecker struct{}
 
 func (c EnglishChecker) Check(s string) bool {
-	return isInRangeTable(s, unicode.Latin)
+	return LatinChecker{Lang: EN}.Check(s)
 }
 
 func (c EnglishChecker) Name() string {
 	return EN
 }
 
+const (
+	// CheckMinLetters 检查器按统计检测判断语言需要的最少字母数，更短的文本只按文字判断
+	CheckMinLetters = 30
+	// CheckMinConfidence 检查器判断文本为其他语言需要的最低置信度
+	CheckMinConfidence = 0.8
+)
+
+// checkDetector 检查器使用的检测器，比默认检测器更保守，只在有把握时判断为其他语言
+var checkDetector = NewDetector(WithMinLetters(CheckMinLetters), WithMinConfidence(CheckMinConfidence))
+
+// LatinChecker 拉丁字母语言检查器，文本只含拉丁字母，且没有被检测为其他语言
+// 太短或置信度不足、无法判断语言的文本只按文字判断，视为目标语言
+type LatinChecker struct {
+	Lang string
+}
+
+func (c LatinChecker) Check(s string) bool {
+	if !isInRangeTable(s, unicode.Latin) {
+		return false
+	}
+	det := checkDetector.Detect(s)
+	return det.Lang == All || det.Lang == c.Lang
+}
+
+func (c LatinChecker) Name() string {
+	return c.Lang
+}
+
 // 日文检查器
 type JapaneseChecker struct{}
 
@@ -148,4 +225,10 @@ var LangMapChecks = map[string]LanguageChecker{
 	RU: RussianChecker{},
 	AR: ArabicChecker{},
 	EL: GreekChecker{},
+	FR: LatinChecker{Lang: FR},
+	DE: LatinChecker{Lang: DE},
+	ES: LatinChecker{Lang: ES},
+	IT: LatinChecker{Lang: IT},
+	PT: LatinChecker{Lang: PT},
+	NL: LatinChecker{Lang: NL},
 }
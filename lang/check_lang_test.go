@@ -66,3 +66,67 @@ func TestEnglishChecker_Check(t *testing.T) {
 		})
 	}
 }
+
+func TestLookup(t *testing.T) {
+	cases := map[string]string{
+		"zh":      ZH,
+		"EN":      EN,
+		"Russian": RU,
+		"日文":      JA,
+		"all":     All,
+		"pt":      PT,
+		"Dutch":   NL,
+	}
+	for name, want := range cases {
+		got, err := Lookup(name)
+		if err != nil || got != want {
+			t.Errorf("Lookup(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	if _, err := Lookup("klingon"); err == nil {
+		t.Error("Expected error for unknown language")
+	}
+}
+
+func TestLatinChecker_Check(t *testing.T) {
+	fr := "Le rapport annuel sera publié le mois prochain."
+	if (EnglishChecker{}).Check(fr) {
+		t.Errorf("French text should not pass the English checker")
+	}
+	if !(LatinChecker{Lang: FR}).Check(fr) {
+		t.Errorf("French text should pass the French checker")
+	}
+	if !(LatinChecker{Lang: DE}).Check("OK") {
+		t.Errorf("Short text should pass any Latin checker")
+	}
+}
+
+func TestEnglishChecker_ProperNouns(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		// 短文本只按文字判断
+		{"Panorama of Milano station", true},
+		{"Welcome to Lago di Como", true},
+		{"Rotterdam Centraal to Den Haag", true},
+		{"Click Save to continue", true},
+		// 专有名词较多的英文
+		{"Meeting with Giovanni Rossi and Maria Bianchi in Roma", true},
+		{"Contact Jean-Pierre Dubois at Société Générale", true},
+		{"The Banco do Brasil branch in São Paulo is closed", true},
+		{"Notre-Dame de Paris reopened in December", true},
+		{"Milano Cortina 2026 Winter Olympics schedule", true},
+		// 其他拉丁字母语言
+		{"Der Jahresbericht wird nächsten Monat veröffentlicht.", false},
+		{"Il rapporto annuale sarà pubblicato il mese prossimo.", false},
+		{"Het jaarverslag wordt volgende maand gepubliceerd.", false},
+		{"Por favor, verifique as informações abaixo.", false},
+	}
+	for _, tt := range tests {
+		if got := (EnglishChecker{}).Check(tt.text); got != tt.want {
+			t.Errorf("EnglishChecker.Check(%q) = %v, want %v (%+v)", tt.text, got, tt.want, checkDetector.Detect(tt.text))
+		}
+	}
+}
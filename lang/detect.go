@@ -0,0 +1,320 @@
+package lang
+
+import (
+	"embed"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// profileFS 拉丁字母语言的训练文本，文件名为语言代码
+// 每种语言覆盖相同的题材：日常叙述、软件界面、商务报告、旅行、科普、合同条款、新闻和对话
+//
+//go:embed profiles/*.txt
+var profileFS embed.FS
+
+const (
+	// maxGram 统计的最长字符 n-gram
+	maxGram = 3
+	// DefaultMinLetters 统计检测需要的最少字母数，更短的拉丁字母文本无法可靠判断
+	DefaultMinLetters = 12
+	// DefaultMinConfidence 默认的最低置信度，低于该值时结果为 All
+	DefaultMinConfidence = 0.5
+	// kanaShare 假名占汉字和假名的比例达到该值时判断为日文
+	kanaShare = 0.1
+	// properWeight 句中首字母大写的单词的权重
+	properWeight = 0.25
+)
+
+// Detection 语言检测结果
+type Detection struct {
+	Lang       string  `json:"lang"`       // 检测到的语言，无法判断时为 All
+	Confidence float64 `json:"confidence"` // 置信度，0 到 1
+	Letters    int     `json:"letters"`    // 参与检测的字母数，合并多个结果时作为权重
+}
+
+// profile 语言的 n-gram 频率
+type profile struct {
+	counts map[string]int
+	total  int
+}
+
+// Detector 语言检测器，按文字判断使用独有文字的语言，拉丁字母语言按字符 n-gram 统计判断
+type Detector struct {
+	profiles      map[string]*profile
+	candidates    []string
+	vocab         int
+	minLetters    int
+	minConfidence float64
+}
+
+// DetectorOpt 语言检测器选项
+type DetectorOpt func(*Detector)
+
+// WithCandidates 只在这些语言中检测
+func WithCandidates(langs ...string) DetectorOpt {
+	return func(d *Detector) {
+		d.candidates = langs
+	}
+}
+
+// WithMinConfidence 设置最低置信度，低于该值时结果为 All
+func WithMinConfidence(c float64) DetectorOpt {
+	return func(d *Detector) {
+		d.minConfidence = c
+	}
+}
+
+// WithMinLetters 设置统计检测需要的最少字母数
+func WithMinLetters(n int) DetectorOpt {
+	return func(d *Detector) {
+		d.minLetters = n
+	}
+}
+
+// DefaultDetector 在所有支持的语言中检测
+var DefaultDetector = NewDetector()
+
+// scriptLangs 使用独有文字的语言
+var scriptLangs = []struct {
+	lang  string
+	table *unicode.RangeTable
+}{
+	{KO, unicode.Hangul},
+	{RU, unicode.Cyrillic},
+	{AR, unicode.Arabic},
+	{EL, unicode.Greek},
+}
+
+// NewDetector 创建语言检测器，训练文本随包嵌入
+func NewDetector(opts ...DetectorOpt) *Detector {
+	d := &Detector{
+		profiles:      map[string]*profile{},
+		minLetters:    DefaultMinLetters,
+		minConfidence: DefaultMinConfidence,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(d)
+		}
+	}
+
+	entries, _ := profileFS.ReadDir("profiles")
+	vocab := map[string]bool{}
+	for _, e := range entries {
+		l := LangCodes[strings.TrimSuffix(e.Name(), ".txt")]
+		if l == "" || !d.candidate(l) {
+			continue
+		}
+		data, _ := profileFS.ReadFile("profiles/" + e.Name())
+		p := &profile{counts: map[string]int{}}
+		for _, g := range ngrams(string(data)) {
+			p.counts[g]++
+			p.total++
+			vocab[g] = true
+		}
+		d.profiles[l] = p
+	}
+	d.vocab = len(vocab) + 1
+	return d
+}
+
+// candidate 语言在候选范围内
+func (d *Detector) candidate(l string) bool {
+	if len(d.candidates) == 0 {
+		return true
+	}
+	for _, c := range d.candidates {
+		if c == l {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect 用默认检测器检测文本的语言
+func Detect(text string) Detection {
+	return DefaultDetector.Detect(text)
+}
+
+// Detect 检测文本的语言，先按文字判断，拉丁字母文本再按 n-gram 统计判断
+func (d *Detector) Detect(text string) Detection {
+	var latin, han, kana, letters int
+	scripts := make([]int, len(scriptLangs))
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		default:
+			for i, s := range scriptLangs {
+				if unicode.Is(s.table, r) {
+					scripts[i]++
+					break
+				}
+			}
+		}
+	}
+	if letters == 0 {
+		return Detection{Lang: All}
+	}
+
+	// 出现最多的文字决定语言
+	best, count := "", 0
+	if han+kana > 0 {
+		best, count = ZH, han+kana
+		if float64(kana)/float64(han+kana) >= kanaShare {
+			best = JA
+		}
+	}
+	for i, s := range scriptLangs {
+		if scripts[i] > count {
+			best, count = s.lang, scripts[i]
+		}
+	}
+	if latin > count {
+		return d.detectLatin(text, latin, letters)
+	}
+	return d.result(best, float64(count)/float64(letters), letters)
+}
+
+// detectLatin 按 n-gram 统计判断拉丁字母文本的语言，结果乘以拉丁字母所占比例
+func (d *Detector) detectLatin(text string, latin, letters int) Detection {
+	if latin < d.minLetters || len(d.profiles) == 0 {
+		return Detection{Lang: All, Letters: letters}
+	}
+
+	grams := weightedNgrams(text)
+	weight := 0.0
+	for _, g := range grams {
+		weight += g.weight
+	}
+	langs := make([]string, 0, len(d.profiles))
+	scores := make([]float64, 0, len(d.profiles))
+	for l, p := range d.profiles {
+		score := 0.0
+		for _, g := range grams {
+			score += g.weight * math.Log(float64(p.counts[g.gram]+1)/float64(p.total+d.vocab))
+		}
+		langs = append(langs, l)
+		scores = append(scores, score)
+	}
+
+	// 对数似然按 n-gram 权重和的平方根缩放后归一化，避免长文本的置信度总是接近 1
+	scale := math.Sqrt(weight)
+	top := 0
+	for i := range scores {
+		scores[i] /= scale
+		if scores[i] > scores[top] || (scores[i] == scores[top] && langs[i] < langs[top]) {
+			top = i
+		}
+	}
+	sum := 0.0
+	for _, s := range scores {
+		sum += math.Exp(s - scores[top])
+	}
+	share := float64(latin) / float64(letters)
+	return d.result(langs[top], share/sum, letters)
+}
+
+// result 置信度不足或语言不在候选范围内时结果为 All
+func (d *Detector) result(l string, confidence float64, letters int) Detection {
+	if !d.candidate(l) || confidence < d.minConfidence {
+		return Detection{Lang: All, Confidence: confidence, Letters: letters}
+	}
+	return Detection{Lang: l, Confidence: confidence, Letters: letters}
+}
+
+// Combine 按字母数加权合并多个检测结果，用于判断整个文档或分块的语言
+// 置信度为检测到该语言的字母占全部字母的比例
+func (d *Detector) Combine(dets ...Detection) Detection {
+	weights := map[string]float64{}
+	letters := 0
+	for _, det := range dets {
+		letters += det.Letters
+		if det.Lang != All {
+			weights[det.Lang] += float64(det.Letters) * det.Confidence
+		}
+	}
+	if letters == 0 {
+		return Detection{Lang: All}
+	}
+
+	langs := make([]string, 0, len(weights))
+	for l := range weights {
+		langs = append(langs, l)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if weights[langs[i]] != weights[langs[j]] {
+			return weights[langs[i]] > weights[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	if len(langs) == 0 {
+		return Detection{Lang: All, Letters: letters}
+	}
+	return d.result(langs[0], weights[langs[0]]/float64(letters), letters)
+}
+
+// weighted 带权重的 n-gram
+type weighted struct {
+	gram   string
+	weight float64
+}
+
+// weightedNgrams 文本中的 n-gram，句中首字母大写的单词多为人名、地名等专有名词，
+// 与文本的语言关系不大，按 properWeight 降低权重
+func weightedNgrams(text string) []weighted {
+	grams := make([]weighted, 0, len(text))
+	first := true
+	for _, w := range latinWords(text) {
+		weight := 1.0
+		if !first && unicode.IsUpper([]rune(w)[0]) {
+			weight = properWeight
+		}
+		first = false
+		for _, g := range wordNgrams(strings.ToLower(w)) {
+			grams = append(grams, weighted{gram: g, weight: weight})
+		}
+	}
+	return grams
+}
+
+// ngrams 文本中长度 1 到 maxGram 的字符 n-gram，按单词切分，单词前后补空格
+func ngrams(text string) []string {
+	grams := make([]string, 0, len(text))
+	for _, w := range latinWords(strings.ToLower(text)) {
+		grams = append(grams, wordNgrams(w)...)
+	}
+	return grams
+}
+
+// latinWords 按非拉丁字母切分出的单词
+func latinWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) || !unicode.Is(unicode.Latin, r)
+	})
+}
+
+// wordNgrams 单词中长度 1 到 maxGram 的字符 n-gram，单词前后补空格
+func wordNgrams(w string) []string {
+	grams := make([]string, 0, 3*len(w))
+	runes := []rune(" " + w + " ")
+	for n := 1; n <= maxGram; n++ {
+		for i := 0; i+n <= len(runes); i++ {
+			if n == 1 && runes[i] == ' ' {
+				continue
+			}
+			grams = append(grams, string(runes[i:i+n]))
+		}
+	}
+	return grams
+}
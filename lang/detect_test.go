@@ -0,0 +1,79 @@
+package lang
+
+import (
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	cases := map[string]string{
+		"The annual report will be published next month.":                        EN,
+		"Le rapport annuel sera publié le mois prochain.":                        FR,
+		"Der Jahresbericht wird nächsten Monat veröffentlicht.":                  DE,
+		"El informe anual se publicará el próximo mes.":                          ES,
+		"Il rapporto annuale sarà pubblicato il mese prossimo.":                  IT,
+		"O relatório anual será publicado no próximo mês.":                       PT,
+		"Het jaarverslag wordt volgende maand gepubliceerd.":                     NL,
+		"年度报告将于下个月发布。":                                                           ZH,
+		"年次報告書は来月公開されます。":                                                        JA,
+		"연례 보고서는 다음 달에 발표됩니다.":                                                   KO,
+		"Годовой отчёт будет опубликован в следующем месяце.":                    RU,
+		"سيتم نشر التقرير السنوي الشهر المقبل.":                                  AR,
+		"Η ετήσια έκθεση θα δημοσιευθεί τον επόμενο μήνα.":                       EL,
+		"Docx4j is a Java library for creating and manipulating Open XML files.": EN,
+		"Meeting with Giovanni Rossi and Maria Bianchi in Roma":                  EN,
+		"The Banco do Brasil branch in São Paulo is closed":                      EN,
+		"OK":         All,
+		"12345, ...": All,
+	}
+	for text, want := range cases {
+		got := Detect(text)
+		if got.Lang != want {
+			t.Errorf("Detect(%q) = %+v, want %s", text, got, want)
+		}
+		if want != All && (got.Confidence < DefaultMinConfidence || got.Confidence > 1) {
+			t.Errorf("Detect(%q) confidence = %v", text, got.Confidence)
+		}
+	}
+}
+
+func TestDetector_Options(t *testing.T) {
+	text := "El informe anual se publicará el próximo mes."
+	d := NewDetector(WithCandidates(EN, FR))
+	if got := d.Detect(text); got.Lang == ES {
+		t.Errorf("Detected a language outside the candidates: %+v", got)
+	}
+	if got := d.Detect("年度报告"); got.Lang != All {
+		t.Errorf("Expected All for a script outside the candidates, got %+v", got)
+	}
+
+	if got := NewDetector(WithMinConfidence(1.1)).Detect(text); got.Lang != All || got.Confidence == 0 {
+		t.Errorf("Expected All with the confidence kept, got %+v", got)
+	}
+	if got := NewDetector(WithMinLetters(2)).Detect("Hello, World!"); got.Lang == All {
+		t.Errorf("Expected a language for short text, got %+v", got)
+	}
+}
+
+func TestDetector_Combine(t *testing.T) {
+	d := DefaultDetector
+	got := d.Combine(
+		Detection{Lang: FR, Confidence: 0.9, Letters: 100},
+		Detection{Lang: EN, Confidence: 1, Letters: 20},
+		Detection{Lang: All, Letters: 10},
+	)
+	if got.Lang != FR || got.Letters != 130 || got.Confidence < 0.69 || got.Confidence > 0.7 {
+		t.Errorf("Unexpected combined detection %+v", got)
+	}
+
+	even := []Detection{
+		{Lang: FR, Confidence: 1, Letters: 10},
+		{Lang: DE, Confidence: 1, Letters: 10},
+		{Lang: ES, Confidence: 1, Letters: 10},
+	}
+	if got := d.Combine(even...); got.Lang != All {
+		t.Errorf("Expected All for an even split, got %+v", got)
+	}
+	if got := d.Combine(); got.Lang != All {
+		t.Errorf("Expected All without detections, got %+v", got)
+	}
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -31,6 +32,7 @@ type DocxLogger struct {
 	needStdio bool
 	filePath  string
 	file      *os.File
+	out       io.Writer
 	mu        sync.Mutex
 	level     LogLevel
 }
@@ -55,11 +57,21 @@ func NewLogger(needStdio bool, outputDir, fileName string) (*DocxLogger, error)
 	return &DocxLogger{
 		filePath:  filePath,
 		file:      file,
+		out:       file,
 		level:     INFO,
 		needStdio: needStdio,
 	}, nil
 }
 
+// NewWriterLogger 创建写入 w 的日志记录器，不创建任何文件
+func NewWriterLogger(w io.Writer, needStdio bool) *DocxLogger {
+	return &DocxLogger{
+		out:       w,
+		level:     INFO,
+		needStdio: needStdio,
+	}
+}
+
 // SetLevel 设置日志级别
 func (l *DocxLogger) SetLevel(level LogLevel) {
 	l.mu.Lock()
@@ -97,8 +109,10 @@ func (l *DocxLogger) writeLog(level LogLevel, format string, args ...interface{}
 	logEntry := fmt.Sprintf("[%s] [%s] %s\n", timestamp, levelStr, message)
 
 	// 写入文件
-	if _, err := l.file.WriteString(logEntry); err != nil {
-		fmt.Printf("写入日志失败: %v\n", err)
+	if l.out != nil {
+		if _, err := io.WriteString(l.out, logEntry); err != nil {
+			fmt.Printf("写入日志失败: %v\n", err)
+		}
 	}
 
 	// 同时输出到控制台
@@ -208,7 +222,24 @@ func (l *DocxLogger) LogStatistics(totalParagraphs, translatedParagraphs, skippe
 	l.Info("总段落数: %d", totalParagraphs)
 	l.Info("已翻译: %d", translatedParagraphs)
 	l.Info("跳过翻译: %d", skippedParagraphs)
-	l.Info("翻译率: %.2f%%", float64(translatedParagraphs)/float64(totalParagraphs)*100)
+	if totalParagraphs > 0 {
+		l.Info("翻译率: %.2f%%", float64(translatedParagraphs)/float64(totalParagraphs)*100)
+	}
+}
+
+// LogUsage 记录 token 用量和费用
+func (l *DocxLogger) LogUsage(model string, requests, promptTokens, completionTokens int, cost float64) {
+	l.Info("用量[%s]: 请求 %d 次, 输入 %d tokens, 输出 %d tokens, 费用 $%.4f",
+		model, requests, promptTokens, completionTokens, cost)
+}
+
+// LogEstimate 记录试运行的预估结果
+func (l *DocxLogger) LogEstimate(requests, promptTokens, completionTokens int, cost float64, duration time.Duration) {
+	l.Info("=== 试运行预估 ===")
+	l.Info("预计请求数: %d", requests)
+	l.Info("预计输入: %d tokens, 预计输出: %d tokens", promptTokens, completionTokens)
+	l.Info("预计费用: $%.4f", cost)
+	l.Info("预计耗时: %v", duration)
 }
 
 // Close 关闭日志记录器
@@ -0,0 +1,103 @@
+package eden
+
+import (
+	"fmt"
+
+	"github.com/gou-jjjj/eden/translate"
+	"github.com/gou-jjjj/unioffice/document"
+)
+
+// partDocument 正文部件，目前只提取正文和正文中的表格
+const partDocument = "document"
+
+// WithJobID 设置任务 ID，随每个请求发送给翻译器
+func WithJobID(id string) Opt {
+	return func(p *DocxProcessor) {
+		p.jobID = id
+	}
+}
+
+// WithLabels 设置自定义标签，随每个请求发送给翻译器
+func WithLabels(labels map[string]string) Opt {
+	return func(p *DocxProcessor) {
+		p.labels = labels
+	}
+}
+
+// WithRequestOptions 设置每个请求的选项，如替换模型、采样温度
+func WithRequestOptions(o translate.ReqOptions) Opt {
+	return func(p *DocxProcessor) {
+		p.reqOptions = o
+	}
+}
+
+// segmentID 片段标识，由段落序号和片段在段落中的序号组成，跳过的片段同样计数
+func segmentID(para, seg int) string {
+	return fmt.Sprintf("p%d.s%d", para, seg)
+}
+
+// cellParagraphs 表格单元格中的段落数，Paragraphs 先返回正文段落再返回表格中的段落
+func cellParagraphs(doc *document.Document) int {
+	n := 0
+	for _, table := range doc.Tables() {
+		for _, row := range table.Rows() {
+			for _, cell := range row.Cells() {
+				n += len(cell.Paragraphs())
+			}
+		}
+	}
+	return n
+}
+
+// styleName 段落样式名，样式表中没有时返回样式 ID
+func (p *DocxProcessor) styleName(paragraph document.Paragraph) string {
+	id := paragraph.Style()
+	if id == "" || p.f.Styles.X() == nil {
+		return id
+	}
+	if style, ok := p.f.Styles.SearchStyleById(id); ok && style.Name() != "" {
+		return style.Name()
+	}
+	return id
+}
+
+// linkSegments 填写每个片段在文档中前后片段的原文
+func linkSegments(segSet [][]translate.Segment, paraSet []translate.Paragraph) {
+	type pos struct{ chunk, seg int }
+	order := make([]pos, 0)
+	for i, segs := range segSet {
+		for j := range segs {
+			order = append(order, pos{i, j})
+		}
+	}
+	for k, at := range order {
+		seg := &segSet[at.chunk][at.seg]
+		if k > 0 {
+			prev := order[k-1]
+			seg.Prev = paraSet[prev.chunk][prev.seg]
+		}
+		if k < len(order)-1 {
+			next := order[k+1]
+			seg.Next = paraSet[next.chunk][next.seg]
+		}
+	}
+}
+
+// segmentsFor 分块中片段的来源信息，没有提取信息时为空
+func (p *DocxProcessor) segmentsFor(idx int) []translate.Segment {
+	if idx < len(p.segSet) {
+		return p.segSet[idx]
+	}
+	return nil
+}
+
+// metaFor 分块所属的任务和文档
+func (p *DocxProcessor) metaFor(idx int) translate.JobMeta {
+	return translate.JobMeta{
+		JobID:    p.jobID,
+		Document: p.fileName,
+		Chunk:    idx,
+		Chunks:   len(p.paraSet),
+		Labels:   p.labels,
+	}
+}
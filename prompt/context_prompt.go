@@ -0,0 +1,50 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SummaryPrompt 文档摘要的系统提示词，摘要用 lang 书写，为空时使用英文
+func SummaryPrompt(lang string) string {
+	if lang == "" {
+		lang = "English"
+	}
+	return fmt.Sprintf(`# Document Summary
+
+**Role**: Professional Translation Expert  
+**Task**: Read the document excerpt and write a brief for the translators who will translate it piece by piece.
+
+Write in %s, at most 150 words, covering:
+
+- **Topic**: what the document is about
+- **Audience**: who it is written for, and the tone and register it uses
+- **Key terms**: names, product names and domain terms that must be translated consistently
+
+Return only the brief. No additional explanations.`, lang)
+}
+
+// ContextPrompt 渲染文档摘要和之前的原文译文，sources 和 targets 按位置对应，译文为空时只列出原文
+func ContextPrompt(summary string, sources, targets []string) string {
+	if summary == "" && len(sources) == 0 {
+		return ""
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString("## Document Context\n\nUse this context to keep terminology, references and tone consistent across segments. Do not translate or repeat it.\n")
+	if summary != "" {
+		builder.WriteString("\n### Summary\n\n")
+		builder.WriteString(summary)
+		builder.WriteString("\n")
+	}
+	if len(sources) > 0 {
+		builder.WriteString("\n### Preceding Text\n\n")
+		for i, source := range sources {
+			builder.WriteString(fmt.Sprintf("- Source: %s\n", source))
+			if i < len(targets) && targets[i] != "" {
+				builder.WriteString(fmt.Sprintf("  Translation: %s\n", targets[i]))
+			}
+		}
+	}
+	return builder.String()
+}
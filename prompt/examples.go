@@ -0,0 +1,153 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/gou-jjjj/eden/lang"
+	"gopkg.in/yaml.v3"
+)
+
+// Example 少样本示例，Source 和 Target 按片段一一对应
+type Example struct {
+	From   string   `yaml:"from" json:"from"`
+	To     string   `yaml:"to" json:"to"`
+	Source []string `yaml:"source" json:"source"`
+	Target []string `yaml:"target" json:"target"`
+}
+
+// Examples 按语言对保存的少样本示例
+type Examples struct {
+	mu    sync.RWMutex
+	pairs map[string][]Example
+}
+
+// NewExamples 创建示例集
+func NewExamples(examples ...Example) *Examples {
+	e := &Examples{pairs: map[string][]Example{}}
+	e.Add(examples...)
+	return e
+}
+
+// pairKey 语言对的键
+func pairKey(from, to string) string {
+	return from + "_" + to
+}
+
+// Add 添加示例
+func (e *Examples) Add(examples ...Example) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, ex := range examples {
+		key := pairKey(ex.From, ex.To)
+		e.pairs[key] = append(e.pairs[key], ex)
+	}
+}
+
+// Set 替换示例中出现的语言对的全部示例，其他语言对不变
+func (e *Examples) Set(examples ...Example) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	replaced := map[string]bool{}
+	for _, ex := range examples {
+		key := pairKey(ex.From, ex.To)
+		if !replaced[key] {
+			e.pairs[key] = nil
+			replaced[key] = true
+		}
+		e.pairs[key] = append(e.pairs[key], ex)
+	}
+}
+
+// Clone 复制示例集
+func (e *Examples) Clone() *Examples {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	c := &Examples{pairs: make(map[string][]Example, len(e.pairs))}
+	for key, list := range e.pairs {
+		c.pairs[key] = append([]Example(nil), list...)
+	}
+	return c
+}
+
+// For 语言对的示例
+// 没有该语言对的示例时，源语言不确定（lang.All）则使用目标语言相同的示例，否则不使用示例，只依靠提示词说明格式
+func (e *Examples) For(from, to string) []Example {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if res, ok := e.pairs[pairKey(from, to)]; ok {
+		return res
+	}
+	if from != lang.All {
+		return nil
+	}
+	for _, l := range []string{lang.ZH, lang.EN, lang.JA, lang.KO, lang.RU, lang.AR, lang.EL} {
+		if res, ok := e.pairs[pairKey(l, to)]; ok {
+			return res
+		}
+	}
+	return nil
+}
+
+// ParseExamples 解析 YAML 或 JSON 格式的示例列表，语言可以写语言代码或语言名
+func ParseExamples(data []byte) ([]Example, error) {
+	examples := make([]Example, 0)
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&examples); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	for i := range examples {
+		ex := &examples[i]
+		from, err := lang.Lookup(ex.From)
+		if err != nil {
+			return nil, fmt.Errorf("example %d: from: %w", i, err)
+		}
+		to, err := lang.Lookup(ex.To)
+		if err != nil || to == lang.All {
+			return nil, fmt.Errorf("example %d: to: target language must be specific", i)
+		}
+		if len(ex.Source) == 0 || len(ex.Source) != len(ex.Target) {
+			return nil, fmt.Errorf("example %d: source and target must have the same number of segments", i)
+		}
+		ex.From, ex.To = from, to
+	}
+	return examples, nil
+}
+
+// LoadExamples 从文件加载示例
+func LoadExamples(file string) ([]Example, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	examples, err := ParseExamples(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return examples, nil
+}
+
+// 内置示例使用的原文，包含命令、路径和时间，演示分段和特殊内容的保留
+var (
+	sampleZH = []string{"要运行程序，请使用：`python main.py --input data.json`", "这将处理数据集并生成", "输出文件到`/results/`目录，截止东部时间下午5点。"}
+	sampleEN = []string{"To run the program, use: `python main.py --input data.json`", "This will process the dataset and generate", "output files in `/results/` directory by 5PM EST."}
+	sampleJA = []string{"プログラムを実行するには、次を使用します：`python main.py --input data.json`", "これによりデータセットが処理され、", "東部時間午後5時までに`/results/`ディレクトリに出力ファイルが生成されます。"}
+	sampleRU = []string{"Чтобы запустить программу, используйте: `python main.py --input data.json`", "Это обработает набор данных и создаст", "выходные файлы в каталоге `/results/` до 17:00 по восточному времени."}
+)
+
+// DefaultExamples 内置示例集，翻译器未指定示例集时使用
+var DefaultExamples = NewExamples(
+	Example{From: lang.ZH, To: lang.EN, Source: sampleZH, Target: sampleEN},
+	Example{From: lang.EN, To: lang.ZH, Source: sampleEN, Target: sampleZH},
+	Example{From: lang.EN, To: lang.JA, Source: sampleEN, Target: sampleJA},
+	Example{From: lang.JA, To: lang.EN, Source: sampleJA, Target: sampleEN},
+	Example{From: lang.EN, To: lang.RU, Source: sampleEN, Target: sampleRU},
+	Example{From: lang.RU, To: lang.EN, Source: sampleRU, Target: sampleEN},
+)
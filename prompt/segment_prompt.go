@@ -0,0 +1,33 @@
+package prompt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NotesPrompt 渲染片段的格式说明，notes 的键为片段从 1 开始的序号，没有说明时返回空字符串
+func NotesPrompt(notes map[int]string) string {
+	if len(notes) == 0 {
+		return ""
+	}
+
+	idx := make([]int, 0, len(notes))
+	for i := range notes {
+		idx = append(idx, i)
+	}
+	sort.Ints(idx)
+
+	builder := strings.Builder{}
+	builder.WriteString("## Segment Formatting\n\n")
+	builder.WriteString("Some segments have a special role in the document. Keep headings short and title-like, and keep table cells concise:\n\n")
+	for _, i := range idx {
+		builder.WriteString(NoteLine(i, notes[i]))
+	}
+	return builder.String()
+}
+
+// NoteLine 渲染单个片段的格式说明，i 为片段从 1 开始的序号
+func NoteLine(i int, note string) string {
+	return fmt.Sprintf("- Segment %d: %s\n", i, note)
+}
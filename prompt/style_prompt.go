@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Style 翻译风格，字段为空表示不作要求
+type Style struct {
+	Domain    string // 文档领域，如 legal、medical、technical、marketing
+	Tone      string // 语气，如 friendly、persuasive
+	Formality string // 正式程度：formal 或 informal
+	Locale    string // 目标语言的地区变体，如 zh-TW、en-GB、pt-BR
+	Audience  string // 目标读者
+}
+
+// Empty 没有任何风格要求
+func (s Style) Empty() bool {
+	return s == Style{}
+}
+
+// domainGuides 各领域的翻译要求
+var domainGuides = map[string]string{
+	"legal":     "Use precise legal terminology, keep defined terms, clause numbers and cross-references consistent, and never paraphrase obligations or conditions.",
+	"medical":   "Use standard medical terminology, and keep drug names, dosages, units and lab values unchanged.",
+	"technical": "Use established technical terminology, and keep code, commands, identifiers, units and UI labels unchanged.",
+	"marketing": "Adapt idioms, wordplay and calls to action so the copy reads naturally and persuasively in the target market rather than literally.",
+}
+
+// formalityGuides 各正式程度的翻译要求
+var formalityGuides = map[string]string{
+	"formal":   "Use a formal register and polite forms of address where the target language distinguishes them (e.g. Sie, vous, usted, 您, です/ます).",
+	"informal": "Use a casual, conversational register and familiar forms of address where the target language distinguishes them (e.g. du, tu, tú, 你).",
+}
+
+// localeGuides 各地区变体的翻译要求，键为小写的地区代码
+var localeGuides = map[string]string{
+	"zh-cn": "Use Simplified Chinese characters and the vocabulary used in mainland China.",
+	"zh-tw": "Use Traditional Chinese characters and the vocabulary used in Taiwan.",
+	"zh-hk": "Use Traditional Chinese characters and the vocabulary used in Hong Kong.",
+	"en-us": "Use American spelling, vocabulary, date and number formats.",
+	"en-gb": "Use British spelling, vocabulary, date and number formats.",
+	"pt-br": "Use Brazilian Portuguese vocabulary, spelling and forms of address.",
+	"pt-pt": "Use European Portuguese vocabulary, spelling and forms of address.",
+	"es-es": "Use the Spanish of Spain, including vosotros where a plural informal address is needed.",
+	"es-mx": "Use Mexican Spanish vocabulary and ustedes for plural address.",
+	"fr-fr": "Use the French of France.",
+	"fr-ca": "Use Canadian French vocabulary and conventions.",
+}
+
+// StylePrompt 渲染翻译风格要求，没有要求时返回空字符串
+func StylePrompt(s Style) string {
+	if s.Empty() {
+		return ""
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString("## Style\n\n")
+	line := func(name, value, guide string) {
+		if value == "" {
+			return
+		}
+		builder.WriteString(fmt.Sprintf("- **%s**: %s", name, value))
+		if guide != "" {
+			builder.WriteString(". " + guide)
+		}
+		builder.WriteString("\n")
+	}
+	line("Domain", s.Domain, domainGuides[strings.ToLower(s.Domain)])
+	line("Tone", s.Tone, "")
+	line("Formality", s.Formality, formalityGuides[strings.ToLower(s.Formality)])
+	line("Target locale", s.Locale, localeGuides[strings.ToLower(s.Locale)])
+	line("Audience", s.Audience, "Choose vocabulary and level of detail that this audience expects.")
+	return builder.String()
+}
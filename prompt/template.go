@@ -0,0 +1,157 @@
+package prompt
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed translate_prompt.md
+var translatePrompt string
+
+//go:embed translate_single_prompt.md
+var translateSinglePrompt string
+
+// Default 内置的翻译提示词模板
+var Default = MustTemplate("default", translatePrompt, translateSinglePrompt)
+
+// Pair 原文和译文，译文尚未完成时为空
+type Pair struct {
+	Source string `json:"source"`
+	Target string `json:"target,omitempty"`
+}
+
+// Vars 渲染翻译提示词的变量，模板中以小写开头的键访问：
+//
+//	{{.fromLang}} {{.toLang}} {{.segments}}
+//	{{.domain}} {{.tone}} {{.formality}} {{.locale}} {{.audience}}
+//	{{.glossary}}  术语表，原文术语到译文的映射
+//	{{.summary}}   文档摘要
+//	{{.previous}}  之前的原文和译文，元素有 Source 和 Target 字段
+//	{{.notes}}     片段的格式说明，键为片段从 1 开始的序号
+//	{{.stylePrompt}} {{.notesPrompt}} {{.glossaryPrompt}} {{.contextPrompt}} 按内置格式渲染好的风格要求、片段说明、术语表和上下文，没有时为空
+type Vars struct {
+	FromLang string
+	ToLang   string
+	Segments int // 本次请求的片段数
+	Style    Style
+	Glossary map[string]string
+	Summary  string
+	Previous []Pair
+	Notes    map[int]string
+}
+
+// data 模板数据
+func (v Vars) data() map[string]interface{} {
+	sources := make([]string, 0, len(v.Previous))
+	targets := make([]string, 0, len(v.Previous))
+	for _, p := range v.Previous {
+		sources = append(sources, p.Source)
+		targets = append(targets, p.Target)
+	}
+
+	return map[string]interface{}{
+		"fromLang":       v.FromLang,
+		"toLang":         v.ToLang,
+		"segments":       v.Segments,
+		"domain":         v.Style.Domain,
+		"tone":           v.Style.Tone,
+		"formality":      v.Style.Formality,
+		"locale":         v.Style.Locale,
+		"audience":       v.Style.Audience,
+		"stylePrompt":    StylePrompt(v.Style),
+		"notes":          v.Notes,
+		"notesPrompt":    NotesPrompt(v.Notes),
+		"glossary":       v.Glossary,
+		"summary":        v.Summary,
+		"previous":       v.Previous,
+		"glossaryPrompt": GlossaryPrompt(v.Glossary),
+		"contextPrompt":  ContextPrompt(v.Summary, sources, targets),
+	}
+}
+
+// Template 翻译提示词模板，单段请求可以使用单独的模板
+type Template struct {
+	name   string
+	multi  *template.Template
+	single *template.Template // 为空时单段请求也使用 multi
+}
+
+// NewTemplate 解析模板，single 为空时所有请求都使用 multi
+func NewTemplate(name, multi, single string) (*Template, error) {
+	t := &Template{name: name}
+	var err error
+	if t.multi, err = template.New(name).Option("missingkey=zero").Parse(multi); err != nil {
+		return nil, err
+	}
+	if single != "" {
+		if t.single, err = template.New(name + ".single").Option("missingkey=zero").Parse(single); err != nil {
+			return nil, err
+		}
+	}
+
+	// 用示例变量试渲染一次，尽早发现模板中的错误
+	if _, err := t.Render(Vars{FromLang: "English", ToLang: "Chinese", Segments: 2}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// MustTemplate 同 NewTemplate，模板错误时 panic
+func MustTemplate(name, multi, single string) *Template {
+	t, err := NewTemplate(name, multi, single)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// LoadTemplate 从文件加载模板，single 为空时所有请求都使用 multi
+func LoadTemplate(multi, single string) (*Template, error) {
+	read := func(file string) (string, error) {
+		if file == "" {
+			return "", nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+
+	m, err := read(multi)
+	if err != nil {
+		return nil, err
+	}
+	s, err := read(single)
+	if err != nil {
+		return nil, err
+	}
+	t, err := NewTemplate(filepath.Base(multi), m, s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", multi, err)
+	}
+	return t, nil
+}
+
+// Name 模板名称
+func (t *Template) Name() string {
+	return t.name
+}
+
+// Render 渲染提示词
+func (t *Template) Render(vars Vars) (string, error) {
+	tmpl := t.multi
+	if vars.Segments == 1 && t.single != nil {
+		tmpl = t.single
+	}
+
+	builder := strings.Builder{}
+	if err := tmpl.Execute(&builder, vars.data()); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,148 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gou-jjjj/eden/lang"
+)
+
+func TestDefault_Render(t *testing.T) {
+	res, err := Default.Render(Vars{
+		FromLang: lang.EN,
+		ToLang:   lang.ZH,
+		Segments: 3,
+		Glossary: map[string]string{"eden": "伊甸"},
+		Summary:  "A user guide.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Translate from English to Chinese", "segment1", "- eden: 伊甸", "A user guide."} {
+		if !strings.Contains(res, want) {
+			t.Errorf("Expected %q in prompt", want)
+		}
+	}
+
+	// 单段请求使用单段模板，没有术语表和上下文时不渲染对应部分
+	single := TranslatePrompt(lang.EN, lang.ZH, 1)
+	if strings.Contains(single, "segment1") || strings.Contains(single, "Glossary") || strings.Contains(single, "Document Context") {
+		t.Errorf("Unexpected single segment prompt %s", single)
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "legal.md")
+	text := `Translate {{.fromLang}} legal text into {{.toLang}}.{{if .domain}} Domain: {{.domain}}.{{end}}{{if .tone}} Tone: {{.tone}}.{{end}}
+{{range $term, $tran := .glossary}}{{$term}}={{$tran}};{{end}}
+{{range .previous}}[{{.Source}}|{{.Target}}]{{end}}`
+	if err := os.WriteFile(file, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := LoadTemplate(file, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := tmpl.Render(Vars{
+		FromLang: lang.EN,
+		ToLang:   lang.ZH,
+		Segments: 1,
+		Style:    Style{Domain: "legal", Tone: "formal"},
+		Glossary: map[string]string{"party": "当事人"},
+		Previous: []Pair{{Source: "Hi.", Target: "你好。"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Translate English legal text into Chinese. Domain: legal. Tone: formal.\nparty=当事人;\n[Hi.|你好。]"
+	if res != want {
+		t.Errorf("Render() = %q, want %q", res, want)
+	}
+
+	if err := os.WriteFile(file, []byte("{{.fromLang"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTemplate(file, ""); err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+	if _, err := LoadTemplate(filepath.Join(dir, "missing.md"), ""); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestExamples(t *testing.T) {
+	if got := DefaultExamples.For(lang.ZH, lang.EN); len(got) != 1 || got[0].Target[0] != sampleEN[0] {
+		t.Errorf("Unexpected ZH->EN examples %+v", got)
+	}
+	// 没有示例的语言对不使用其他语言对的示例
+	if got := DefaultExamples.For(lang.KO, lang.EN); got != nil {
+		t.Errorf("Unexpected KO->EN examples %+v", got)
+	}
+	if got := DefaultExamples.For(lang.All, lang.JA); len(got) != 1 || got[0].To != lang.JA {
+		t.Errorf("Unexpected All->JA examples %+v", got)
+	}
+
+	loaded, err := ParseExamples([]byte(`
+- from: en
+  to: ko
+  source: [Hello, world]
+  target: [안녕하세요, 세계]
+- from: zh
+  to: en
+  source: [你好]
+  target: [Hello]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	examples := DefaultExamples.Clone()
+	examples.Set(loaded...)
+	if got := examples.For(lang.EN, lang.KO); len(got) != 1 || got[0].Target[1] != "세계" {
+		t.Errorf("Unexpected EN->KO examples %+v", got)
+	}
+	if got := examples.For(lang.ZH, lang.EN); len(got) != 1 || got[0].Source[0] != "你好" {
+		t.Errorf("Expected ZH->EN examples to be replaced, got %+v", got)
+	}
+	if got := DefaultExamples.For(lang.ZH, lang.EN); got[0].Source[0] == "你好" {
+		t.Error("Clone modified the default examples")
+	}
+
+	for _, bad := range []string{
+		"- {from: xx, to: en, source: [a], target: [b]}",
+		"- {from: en, to: all, source: [a], target: [b]}",
+		"- {from: en, to: zh, source: [a, b], target: [b]}",
+		"- {from: en, to: zh, src: [a], target: [b]}",
+	} {
+		if _, err := ParseExamples([]byte(bad)); err == nil {
+			t.Errorf("Expected an error for %s", bad)
+		}
+	}
+}
+
+func TestStylePrompt(t *testing.T) {
+	if got := StylePrompt(Style{}); got != "" {
+		t.Errorf("Expected no style prompt, got %q", got)
+	}
+
+	got := StylePrompt(Style{Domain: "legal", Formality: "formal", Locale: "zh-TW", Audience: "lawyers"})
+	for _, want := range []string{"**Domain**: legal. Use precise legal terminology", "**Formality**: formal", "**Target locale**: zh-TW. Use Traditional Chinese", "**Audience**: lawyers"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in %q", want, got)
+		}
+	}
+	if strings.Contains(got, "Tone") {
+		t.Errorf("Unexpected tone in %q", got)
+	}
+
+	res, err := Default.Render(Vars{FromLang: lang.EN, ToLang: lang.ZH, Segments: 2, Style: Style{Locale: "pt-BR"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(res, "## Style") || !strings.Contains(res, "Brazilian Portuguese") {
+		t.Errorf("Expected style section in %q", res)
+	}
+}
@@ -1,44 +1,41 @@
 package prompt
 
 import (
-	_ "embed"
+	"fmt"
+	"sort"
 	"strings"
-	"text/template"
 )
 
-//go:embed translate_prompt.md
-var translatePrompt string
-
-//go:embed translate_single_prompt.md
-var translateSinglePrompt string
-
+// TranslatePrompt 用默认模板渲染翻译提示词，segLen 为 1 时使用单段模板
 func TranslatePrompt(fromLang, toLang string, segLen ...int) string {
-	// 解析模板
-	prompt := translatePrompt
+	vars := Vars{FromLang: fromLang, ToLang: toLang}
 	if len(segLen) >= 1 {
-		switch segLen[0] {
-		case 1:
-			prompt = translateSinglePrompt
-		default:
-		}
+		vars.Segments = segLen[0]
 	}
 
-	tmpl, err := template.New("translatePrompt").Parse(prompt)
+	res, err := Default.Render(vars)
 	if err != nil {
 		panic(err)
 	}
+	return res
+}
 
-	data := map[string]interface{}{
-		"fromLang": fromLang,
-		"toLang":   toLang,
+// GlossaryPrompt 渲染术语表，术语按原文排序，术语表为空时返回空字符串
+func GlossaryPrompt(glossary map[string]string) string {
+	if len(glossary) == 0 {
+		return ""
 	}
 
-	// 执行模板，把渲染结果输出到标准输出
-	builder := strings.Builder{}
-	err = tmpl.Execute(&builder, data)
-	if err != nil {
-		panic(err)
+	terms := make([]string, 0, len(glossary))
+	for term := range glossary {
+		terms = append(terms, term)
 	}
+	sort.Strings(terms)
 
+	builder := strings.Builder{}
+	builder.WriteString("## Glossary\n\nAlways translate the following terms exactly as listed:\n\n")
+	for _, term := range terms {
+		builder.WriteString(fmt.Sprintf("- %s: %s\n", term, glossary[term]))
+	}
 	return builder.String()
 }
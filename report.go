@@ -0,0 +1,135 @@
+package eden
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/translate"
+)
+
+// ErrTooManyFailures 失败分块超过允许的比例
+var ErrTooManyFailures = errors.New("too many failed chunks")
+
+// ChunkStatus 分块状态
+type ChunkStatus string
+
+const (
+	ChunkTranslated ChunkStatus = "translated" // 翻译成功
+	ChunkSkipped    ChunkStatus = "skipped"    // 已是目标语言，跳过
+	ChunkFailed     ChunkStatus = "failed"     // 翻译失败，保留原文
+)
+
+// ChunkResult 分块翻译结果
+type ChunkResult struct {
+	Index    int                    `json:"index"`
+	Status   ChunkStatus            `json:"status"`
+	Segments translate.Paragraph    `json:"segments"`
+	Resumed  bool                   `json:"resumed,omitempty"` // 译文来自检查点
+	Error    string                 `json:"error,omitempty"`
+	Err      error                  `json:"-"`
+	Usage    translate.UsageByModel `json:"usage,omitempty"`
+	Cost     float64                `json:"cost"`
+}
+
+// Report 文档处理报告
+type Report struct {
+	Input      string                 `json:"input"`
+	Output     string                 `json:"output"`
+	From       string                 `json:"from"`
+	To         string                 `json:"to"`
+	Translator string                 `json:"translator"`
+	Chunks     []ChunkResult          `json:"chunks"`
+	Translated int                    `json:"translated"` // 翻译成功的分块数
+	Skipped    int                    `json:"skipped"`    // 跳过的分块数
+	Failed     int                    `json:"failed"`     // 翻译失败的分块数
+	Usage      translate.UsageByModel `json:"usage"`
+	Cost       float64                `json:"cost"`
+	Duration   time.Duration          `json:"duration"`
+	Estimate   *Estimate              `json:"estimate,omitempty"` // 试运行时的预估结果
+	Summary    string                 `json:"summary,omitempty"`  // 翻译前生成的文档摘要
+	Detected   *lang.Detection        `json:"detected,omitempty"` // 源语言为 lang.All 时检测到的文档语言
+	Targets    []*Report              `json:"targets,omitempty"`  // 多个目标语言时每种语言的报告
+}
+
+// newReport 创建空报告
+func newReport() *Report {
+	return &Report{
+		Chunks: make([]ChunkResult, 0),
+		Usage:  translate.UsageByModel{},
+	}
+}
+
+// addChunk 记录一个分块的结果和用量，调用方需持有锁
+func (r *Report) addChunk(res ChunkResult, prices translate.PriceTable) {
+	res.Cost = prices.Cost(res.Usage)
+	r.Chunks = append(r.Chunks, res)
+	r.Usage.Merge(res.Usage)
+	r.Cost += res.Cost
+
+	switch res.Status {
+	case ChunkTranslated:
+		r.Translated++
+	case ChunkSkipped:
+		r.Skipped++
+	case ChunkFailed:
+		r.Failed++
+	}
+}
+
+// merge 汇总目标语言报告的计数和用量
+func (r *Report) merge(o *Report) {
+	r.Translated += o.Translated
+	r.Skipped += o.Skipped
+	r.Failed += o.Failed
+	r.Usage.Merge(o.Usage)
+	r.Cost += o.Cost
+}
+
+// sortChunks 按分块序号排序
+func (r *Report) sortChunks() {
+	sort.Slice(r.Chunks, func(i, j int) bool {
+		return r.Chunks[i].Index < r.Chunks[j].Index
+	})
+}
+
+// Total 分块总数
+func (r *Report) Total() int {
+	return r.Translated + r.Skipped + r.Failed
+}
+
+// FailureRatio 失败分块在实际请求的分块中的占比
+func (r *Report) FailureRatio() float64 {
+	attempted := r.Translated + r.Failed
+	if attempted == 0 {
+		return 0
+	}
+	return float64(r.Failed) / float64(attempted)
+}
+
+// ChunksByStatus 指定状态的分块
+func (r *Report) ChunksByStatus(status ChunkStatus) []ChunkResult {
+	res := make([]ChunkResult, 0)
+	for _, c := range r.Chunks {
+		if c.Status == status {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// Err 所有失败分块的错误，包括各目标语言的报告，没有失败时返回 nil
+func (r *Report) Err() error {
+	errs := make([]error, 0, r.Failed)
+	for _, c := range r.ChunksByStatus(ChunkFailed) {
+		errs = append(errs, fmt.Errorf("chunk %d: %w", c.Index, c.Err))
+	}
+	for _, t := range r.Targets {
+		if err := t.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.To, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,97 @@
+package segment
+
+import (
+	"github.com/gou-jjjj/eden/lang"
+)
+
+const (
+	// closing 句末标点后的右引号和右括号
+	closing = `['"”’»」』）)\]】]*`
+
+	// westernEnd 西文句末标点，后面需要有空白
+	westernEnd = `[.!?…]+` + closing
+	// cjkEnd 中日文全角句末标点，后面不需要空白
+	cjkEnd = `[。！？]+[…]*` + closing
+)
+
+// Abbrevs 各语言常见的缩写，不含句点
+var Abbrevs = map[string][]string{
+	lang.EN: {
+		"Mr", "Mrs", "Ms", "Dr", "Prof", "Sr", "Jr", "St", "Mt", "vs", "etc", "e.g", "i.e", "cf", "al",
+		"Inc", "Ltd", "Co", "Corp", "Dept", "Univ", "No", "Nos", "Fig", "Figs", "Eq", "Vol", "pp", "p",
+		"approx", "est", "min", "max", "Jan", "Feb", "Mar", "Apr", "Jun", "Jul", "Aug", "Sep", "Sept",
+		"Oct", "Nov", "Dec", "U.S", "U.K", "a.m", "p.m", "Gen", "Gov", "Sen", "Rep", "Rev", "Capt", "Lt", "Col",
+	},
+	lang.RU: {
+		"г", "гг", "т.е", "т.д", "т.п", "т.к", "др", "пр", "см", "ср", "ул", "д", "им", "проф", "акад",
+		"стр", "рис", "табл", "руб", "коп", "тыс", "млн", "млрд", "гл", "т", "с", "н.э", "до н.э",
+	},
+	lang.EL: {
+		"κ", "κα", "π.χ", "δηλ", "κ.λπ", "κ.ά", "σελ", "βλ", "αρ", "π.Χ", "μ.Χ", "Δρ",
+	},
+	lang.KO: {
+		"Mr", "Mrs", "Dr", "Prof", "etc", "e.g", "i.e", "vs", "No", "Inc", "Co", "Ltd",
+	},
+	lang.AR: {
+		"Mr", "Dr", "Prof", "etc", "e.g", "i.e", "No", "Inc", "Co", "Ltd",
+	},
+	lang.FR: {
+		"M", "MM", "Mme", "Mlle", "Dr", "Pr", "St", "Ste", "etc", "cf", "p", "pp", "env", "vol", "chap",
+		"janv", "févr", "avr", "juil", "sept", "oct", "nov", "déc", "n°", "av", "apr", "J.-C",
+	},
+	lang.DE: {
+		"Hr", "Fr", "Dr", "Prof", "St", "Nr", "Str", "Abs", "Abb", "Bd", "Kap", "S", "ca", "bzw", "usw",
+		"z.B", "d.h", "u.a", "v.a", "z.T", "o.ä", "u.U", "evtl", "ggf", "inkl", "vgl", "Jan", "Feb",
+		"Jh", "Mio", "Mrd", "Tel", "GmbH",
+	},
+	lang.ES: {
+		"Sr", "Sra", "Srta", "Dr", "Dra", "Lic", "Ing", "Prof", "Ud", "Uds", "Vd", "Vds", "etc", "pág",
+		"págs", "núm", "cap", "vol", "aprox", "p.ej", "EE.UU", "a.C", "d.C", "S.A",
+	},
+	lang.IT: {
+		"Sig", "Sigg", "Sig.ra", "Dott", "Dott.ssa", "Prof", "Ing", "Avv", "On", "S", "etc", "pag", "pagg",
+		"cap", "vol", "ecc", "cfr", "es", "n", "a.C", "d.C", "S.p.A",
+	},
+	lang.PT: {
+		"Sr", "Sra", "Srta", "Dr", "Dra", "Prof", "Eng", "V.Exa", "etc", "pág", "págs", "cap", "vol",
+		"n", "nº", "p.ex", "a.C", "d.C", "S.A", "Lda", "Ltda",
+	},
+	lang.NL: {
+		"dhr", "mevr", "mr", "dr", "drs", "ir", "prof", "ing", "bijv", "o.a", "d.w.z", "m.b.t", "i.p.v",
+		"t.a.v", "enz", "e.d", "blz", "nr", "jan", "feb", "aug", "sept", "okt", "dec", "B.V", "N.V",
+	},
+}
+
+// LangRules 语言的默认规则，顺序为：缩写例外、通用例外、断句规则
+func LangRules(l string) []Rule {
+	rules := make([]Rule, 0)
+	switch l {
+	case lang.ZH, lang.JA:
+		// 中日文中混排的英文缩写
+		rules = append(rules, Abbreviations(Abbrevs[lang.EN]...))
+	case lang.All:
+		rules = append(rules, Abbreviations(Abbrevs[lang.EN]...), Abbreviations(Abbrevs[lang.RU]...))
+	default:
+		rules = append(rules, Abbreviations(Abbrevs[l]...))
+	}
+
+	rules = append(rules,
+		// 人名首字母，如 J. Smith
+		Rule{Before: `(?:^|[^\p{L}])\p{Lu}\.`, After: `\s+\p{Lu}`},
+		// 句末标点后是小写字母，句子还没有结束
+		Rule{Before: westernEnd, After: `\s+\p{Ll}`},
+	)
+
+	switch l {
+	case lang.ZH, lang.JA:
+		rules = append(rules, Rule{Break: true, Before: cjkEnd, After: ``})
+	case lang.AR:
+		rules = append(rules, Rule{Break: true, Before: `[؟۔]+` + closing, After: `\s`})
+	case lang.EL:
+		// 希腊文用 ; 作问号
+		rules = append(rules, Rule{Break: true, Before: `;` + closing, After: `\s`})
+	case lang.All:
+		rules = append(rules, Rule{Break: true, Before: cjkEnd, After: ``})
+	}
+	return append(rules, Rule{Break: true, Before: westernEnd, After: `\s`})
+}
@@ -0,0 +1,203 @@
+// Package segment 按语言规则把文本拆分为句子，规则参考 SRX：
+// 每个候选断点按顺序匹配规则，第一个前后文都匹配的规则决定是否断句
+package segment
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gou-jjjj/eden/lang"
+)
+
+// Span 句子在原文中的字节位置，不含首尾空白
+type Span struct {
+	Start int
+	End   int
+}
+
+// Segmenter 分句器接口
+type Segmenter interface {
+	Split(text string) []Span
+	Name() string
+}
+
+// Rule 断句规则，Before 匹配断点前的文本，After 匹配断点后的文本
+// Break 为 false 时表示例外，如缩写后的句点不断句
+type Rule struct {
+	Break  bool
+	Before string
+	After  string
+}
+
+// Abbreviations 缩写例外规则，缩写后跟句点和空白时不断句
+func Abbreviations(abbrs ...string) Rule {
+	quoted := make([]string, 0, len(abbrs))
+	for _, a := range abbrs {
+		quoted = append(quoted, regexp.QuoteMeta(strings.TrimSuffix(a, ".")))
+	}
+	// 长的缩写优先匹配
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	return Rule{
+		Before: `(?:^|[^\p{L}\p{N}.])(?:` + strings.Join(quoted, "|") + `)\.`,
+		After:  `\s`,
+	}
+}
+
+// lookBehind 匹配断点前文本时最多向前查看的字节数
+const lookBehind = 64
+
+// rule 编译后的规则
+type rule struct {
+	brk    bool
+	before *regexp.Regexp // 锚定在结尾
+	after  *regexp.Regexp // 锚定在开头
+	scan   *regexp.Regexp // 断句规则用于查找候选断点
+}
+
+// RuleSegmenter 基于规则的分句器
+type RuleSegmenter struct {
+	name  string
+	rules []rule
+}
+
+// NewRules 按顺序编译规则，至少需要一条断句规则
+func NewRules(name string, rules ...Rule) (*RuleSegmenter, error) {
+	s := &RuleSegmenter{name: name}
+	hasBreak := false
+	for i, r := range rules {
+		before, err := regexp.Compile(`(?:` + r.Before + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: before: %w", i, err)
+		}
+		after, err := regexp.Compile(`^(?:` + r.After + `)`)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: after: %w", i, err)
+		}
+		cr := rule{brk: r.Break, before: before, after: after}
+		if r.Break {
+			hasBreak = true
+			cr.scan = regexp.MustCompile(r.Before)
+		}
+		s.rules = append(s.rules, cr)
+	}
+	if !hasBreak {
+		return nil, fmt.Errorf("no break rule")
+	}
+	return s, nil
+}
+
+// MustRules 同 NewRules，规则错误时 panic
+func MustRules(name string, rules ...Rule) *RuleSegmenter {
+	s, err := NewRules(name, rules...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Name 分句器名称
+func (s *RuleSegmenter) Name() string {
+	return s.name
+}
+
+// Split 拆分句子，返回的句子不含首尾空白，空白文本返回空
+func (s *RuleSegmenter) Split(text string) []Span {
+	spans := make([]Span, 0)
+	start := 0
+	for _, pos := range s.breaks(text) {
+		spans = appendSpan(spans, text, start, pos)
+		start = pos
+	}
+	return appendSpan(spans, text, start, len(text))
+}
+
+// breaks 所有断点，按位置排序
+func (s *RuleSegmenter) breaks(text string) []int {
+	candidates := map[int]bool{}
+	for _, r := range s.rules {
+		if r.scan == nil {
+			continue
+		}
+		for _, m := range r.scan.FindAllStringIndex(text, -1) {
+			if m[1] > 0 && m[1] < len(text) {
+				candidates[m[1]] = true
+			}
+		}
+	}
+
+	res := make([]int, 0, len(candidates))
+	for pos := range candidates {
+		if s.isBreak(text, pos) {
+			res = append(res, pos)
+		}
+	}
+	sort.Ints(res)
+	return res
+}
+
+// isBreak 按顺序匹配规则，第一条前后文都匹配的规则决定是否断句
+func (s *RuleSegmenter) isBreak(text string, pos int) bool {
+	from := pos - lookBehind
+	if from < 0 {
+		from = 0
+	}
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from++
+	}
+	before, after := text[from:pos], text[pos:]
+
+	for _, r := range s.rules {
+		if r.before.MatchString(before) && r.after.MatchString(after) {
+			return r.brk
+		}
+	}
+	return false
+}
+
+// appendSpan 去掉首尾空白后追加非空句子
+func appendSpan(spans []Span, text string, start, end int) []Span {
+	seg := text[start:end]
+	trimmed := strings.TrimLeftFunc(seg, unicode.IsSpace)
+	start += len(seg) - len(trimmed)
+	trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+	if trimmed == "" {
+		return spans
+	}
+	return append(spans, Span{Start: start, End: start + len(trimmed)})
+}
+
+// Sentences 拆分句子并返回句子文本
+func Sentences(s Segmenter, text string) []string {
+	spans := s.Split(text)
+	res := make([]string, 0, len(spans))
+	for _, sp := range spans {
+		res = append(res, text[sp.Start:sp.End])
+	}
+	return res
+}
+
+var (
+	cache   = map[string]*RuleSegmenter{}
+	cacheMu sync.Mutex
+)
+
+// ForLang 语言的默认分句器，未知语言和 lang.All 使用通用规则
+func ForLang(l string) Segmenter {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	if _, ok := Abbrevs[l]; !ok && l != lang.ZH && l != lang.JA {
+		l = lang.All
+	}
+	if s, ok := cache[l]; ok {
+		return s
+	}
+	s := MustRules(l, LangRules(l)...)
+	cache[l] = s
+	return s
+}
@@ -0,0 +1,54 @@
+package segment
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gou-jjjj/eden/lang"
+)
+
+func TestForLang_Sentences(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		text string
+		want []string
+	}{
+		{"english", lang.EN, "Hello world. How are you? Fine!", []string{"Hello world.", "How are you?", "Fine!"}},
+		{"abbreviations", lang.EN, "Mr. Smith met Dr. Jones, e.g. at 3 p.m. today. Done.", []string{"Mr. Smith met Dr. Jones, e.g. at 3 p.m. today.", "Done."}},
+		{"initials and decimals", lang.EN, "J. R. R. Tolkien paid 3.50 dollars. Then left.", []string{"J. R. R. Tolkien paid 3.50 dollars.", "Then left."}},
+		{"quotes", lang.EN, `He said "Stop." Then he left.`, []string{`He said "Stop."`, "Then he left."}},
+		{"lowercase continues", lang.EN, "Wait... and then it happened.", []string{"Wait... and then it happened."}},
+		{"whitespace", lang.EN, "  One.   Two.  ", []string{"One.", "Two."}},
+		{"url", lang.EN, "See example.com/a?b=1 for details.", []string{"See example.com/a?b=1 for details."}},
+		{"chinese", lang.ZH, "今天天气很好。我们去公园吧！你去吗？", []string{"今天天气很好。", "我们去公园吧！", "你去吗？"}},
+		{"chinese quotes", lang.ZH, "他说：“好的。”然后走了。", []string{"他说：“好的。”", "然后走了。"}},
+		{"japanese", lang.JA, "これはペンです。あれは本です。", []string{"これはペンです。", "あれは本です。"}},
+		{"russian", lang.RU, "Он родился в 1990 г. в Москве. Потом уехал, т.е. навсегда.", []string{"Он родился в 1990 г. в Москве.", "Потом уехал, т.е. навсегда."}},
+		{"greek", lang.EL, "Τι κάνεις; Καλά.", []string{"Τι κάνεις;", "Καλά."}},
+		{"arabic", lang.AR, "كيف حالك؟ أنا بخير.", []string{"كيف حالك؟", "أنا بخير."}},
+		{"mixed", lang.All, "第一句。Second sentence. 第三句！", []string{"第一句。", "Second sentence.", "第三句！"}},
+		{"empty", lang.EN, "   ", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sentences(ForLang(tt.lang), tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Sentences(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRules(t *testing.T) {
+	if _, err := NewRules("bad", Rule{Before: `\.`, After: `\s`}); err == nil {
+		t.Error("expected an error without a break rule")
+	}
+	if _, err := NewRules("bad", Rule{Break: true, Before: `(`, After: ``}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+
+	s := MustRules("semicolon", Rule{Break: true, Before: `;`, After: `\s`})
+	if got := Sentences(s, "a; b; c"); !reflect.DeepEqual(got, []string{"a;", "b;", "c"}) {
+		t.Errorf("Sentences = %q", got)
+	}
+}
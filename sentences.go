@@ -0,0 +1,80 @@
+package eden
+
+import (
+	"strings"
+
+	"github.com/gou-jjjj/eden/segment"
+	"github.com/gou-jjjj/unioffice/document"
+)
+
+// WithSegmenter 按句子而不是按文本块提取译文，跨文本块的句子作为一个整体翻译
+// 译文写回句子所在的第一个文本块，使用该文本块的格式
+func WithSegmenter(s segment.Segmenter) Opt {
+	return func(p *DocxProcessor) {
+		p.segmenter = s
+	}
+}
+
+// runTexts 段落中每个文本块的文本
+func runTexts(runs []document.Run) []string {
+	texts := make([]string, 0, len(runs))
+	for _, r := range runs {
+		texts = append(texts, r.Text())
+	}
+	return texts
+}
+
+// segments 段落中需要翻译的片段，没有分句器时每个文本块是一个片段
+func (p *DocxProcessor) segments(runs []document.Run) []string {
+	texts := runTexts(runs)
+	if p.segmenter == nil {
+		return texts
+	}
+	return segment.Sentences(p.segmenter, strings.Join(texts, ""))
+}
+
+// rewriteRuns 把段落中的句子替换为译文，返回每个文本块的新文本
+// 跨文本块的句子译文放在第一个文本块，其余部分删除，句子之间的空白保持不变
+func rewriteRuns(seg segment.Segmenter, texts []string, tran map[string]string) []string {
+	full := strings.Join(texts, "")
+	type replace struct {
+		segment.Span
+		text string
+	}
+	reps := make([]replace, 0)
+	for _, sp := range seg.Split(full) {
+		if t, ok := tran[full[sp.Start:sp.End]]; ok {
+			reps = append(reps, replace{Span: sp, text: t})
+		}
+	}
+
+	res := make([]string, len(texts))
+	start := 0
+	k := 0 // 第一个未结束的替换
+	for i, text := range texts {
+		end := start + len(text)
+		var b strings.Builder
+		pos := start
+		for pos < end {
+			for k < len(reps) && reps[k].End <= pos {
+				k++
+			}
+			if k < len(reps) && reps[k].Start <= pos {
+				if reps[k].Start == pos {
+					b.WriteString(reps[k].text)
+				}
+				pos = min(reps[k].End, end)
+				continue
+			}
+			next := end
+			if k < len(reps) && reps[k].Start < end {
+				next = reps[k].Start
+			}
+			b.WriteString(full[pos:next])
+			pos = next
+		}
+		res[i] = b.String()
+		start = end
+	}
+	return res
+}
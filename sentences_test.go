@@ -0,0 +1,83 @@
+package eden
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/segment"
+	"github.com/gou-jjjj/unioffice/document"
+)
+
+func TestRewriteRuns(t *testing.T) {
+	seg := segment.ForLang(lang.EN)
+	tran := map[string]string{
+		"One.":            "Eins.",
+		"Two spans runs.": "Zwei.",
+	}
+
+	texts := []string{"One. Two ", "spans", " runs. Three is untouched."}
+	got := rewriteRuns(seg, texts, tran)
+	want := []string{"Eins. Zwei.", "", " Three is untouched."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rewriteRuns = %q, want %q", got, want)
+	}
+
+	if got := rewriteRuns(seg, []string{"No match."}, tran); got[0] != "No match." {
+		t.Errorf("rewriteRuns = %q", got)
+	}
+}
+
+func TestProcess_Segmenter(t *testing.T) {
+	src, err := os.ReadFile("./file_examples/Docx4j_GettingStarted.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	pr := NewDocxProcessor(
+		WithBytes(src),
+		WithWriter(out),
+		WithLogWriter(io.Discard),
+		WithLang(lang.EN, lang.JA),
+		WithProcessFunc(&stubTran{tag: true}),
+		WithSegmenter(segment.ForLang(lang.EN)))
+	report, err := pr.Process()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Translated == 0 {
+		t.Fatal("nothing translated")
+	}
+
+	// 每个片段最多包含一个句子
+	seg := segment.ForLang(lang.EN)
+	for _, chunk := range pr.paraSet {
+		for _, s := range chunk {
+			if n := len(seg.Split(s)); n != 1 {
+				t.Errorf("segment %q has %d sentences", s, n)
+			}
+		}
+	}
+
+	doc, err := document.Read(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+	tagged := 0
+	for _, para := range doc.Paragraphs() {
+		text := ""
+		for _, r := range para.Runs() {
+			text += r.Text()
+		}
+		tagged += strings.Count(text, "<"+lang.JA+">")
+	}
+	if tagged == 0 {
+		t.Error("no translations written back")
+	}
+}
@@ -0,0 +1,274 @@
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gou-jjjj/eden"
+	"github.com/gou-jjjj/eden/config"
+	"github.com/gou-jjjj/eden/lang"
+)
+
+// JobStatus 任务状态
+type JobStatus string
+
+const (
+	StatusQueued    JobStatus = "queued"    // 等待处理
+	StatusRunning   JobStatus = "running"   // 正在翻译
+	StatusSucceeded JobStatus = "succeeded" // 全部分块翻译成功
+	StatusPartial   JobStatus = "partial"   // 已保存，但部分分块翻译失败保留了原文
+	StatusFailed    JobStatus = "failed"    // 翻译失败，没有输出
+	StatusCanceled  JobStatus = "canceled"  // 开始处理前被删除或服务关闭
+)
+
+// finished 任务已经结束
+func (s JobStatus) finished() bool {
+	return s != StatusQueued && s != StatusRunning
+}
+
+// Job 翻译任务
+type Job struct {
+	mu sync.Mutex
+
+	id       string
+	name     string
+	config   *config.Job
+	input    []byte
+	status   JobStatus
+	created  time.Time
+	started  time.Time
+	finished time.Time
+	total    int
+	done     int
+	failed   int
+	report   *eden.Report
+	err      error
+	outputs  map[string]*bytes.Buffer // 按目标语言保存的译文，只包含保存成功的
+	pending  map[string]*bytes.Buffer // 正在保存的译文，保存成功后移入 outputs
+	langs    []string                 // 目标语言，按配置顺序
+	logs     bytes.Buffer             // 未写完的日志行
+	history  []message                // 最近发布的消息，按序号循环存放，最多 limit 条
+	limit    int                      // 保留的消息数
+	seq      int                      // 最后一条消息的序号
+	notify   chan struct{}            // 发布新消息时关闭并替换
+
+	webhook    string     // 任务结束时的通知地址
+	deliveries []Delivery // 通知请求记录
+}
+
+// JobView 任务状态，用于接口返回
+type JobView struct {
+	ID       string       `json:"id"`
+	Name     string       `json:"name"`
+	Status   JobStatus    `json:"status"`
+	Provider string       `json:"provider"`
+	From     string       `json:"from"`
+	To       []string     `json:"to"`
+	Created  time.Time    `json:"created"`
+	Started  *time.Time   `json:"started,omitempty"`
+	Finished *time.Time   `json:"finished,omitempty"`
+	Total    int          `json:"total"`  // 分块总数
+	Done     int          `json:"done"`   // 已完成的分块数
+	Failed   int          `json:"failed"` // 失败的分块数
+	Outputs  []string     `json:"outputs,omitempty"`
+	Report   *eden.Report `json:"report,omitempty"`
+	Error    string       `json:"error,omitempty"`
+
+	Webhook    string     `json:"webhook,omitempty"`
+	Deliveries []Delivery `json:"deliveries,omitempty"` // 通知请求记录
+}
+
+// newJobID 生成随机任务 ID
+func newJobID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newJob 创建等待处理的任务
+func newJob(name string, input []byte, cfg *config.Job, now time.Time) *Job {
+	langs := make([]string, 0, len(cfg.To))
+	for _, code := range cfg.To {
+		l, _ := lang.Lookup(code)
+		langs = append(langs, l)
+	}
+	if len(langs) == 0 {
+		langs = append(langs, lang.EN)
+	}
+
+	return &Job{
+		id:      newJobID(),
+		name:    name,
+		config:  cfg,
+		input:   input,
+		status:  StatusQueued,
+		created: now,
+		outputs: map[string]*bytes.Buffer{},
+		pending: map[string]*bytes.Buffer{},
+		langs:   langs,
+		limit:   DefaultEventHistory,
+		notify:  make(chan struct{}),
+	}
+}
+
+// ID 任务 ID
+func (j *Job) ID() string {
+	return j.id
+}
+
+// Status 任务状态
+func (j *Job) Status() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// View 任务状态快照
+func (j *Job) View() JobView {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.view()
+}
+
+// view 任务状态快照，调用方需要持有锁
+func (j *Job) view() JobView {
+	v := JobView{
+		ID:       j.id,
+		Name:     j.name,
+		Status:   j.status,
+		Provider: j.config.Provider,
+		From:     j.config.From,
+		To:       j.config.To,
+		Created:  j.created,
+		Total:    j.total,
+		Done:     j.done,
+		Failed:   j.failed,
+		Report:   j.report,
+
+		Webhook:    j.webhook,
+		Deliveries: append([]Delivery(nil), j.deliveries...),
+	}
+	if !j.started.IsZero() {
+		started := j.started
+		v.Started = &started
+	}
+	if !j.finished.IsZero() {
+		finished := j.finished
+		v.Finished = &finished
+	}
+	if j.status == StatusSucceeded || j.status == StatusPartial {
+		for _, l := range j.langs {
+			if _, ok := j.outputs[l]; ok {
+				v.Outputs = append(v.Outputs, l)
+			}
+		}
+	}
+	if j.err != nil {
+		v.Error = j.err.Error()
+	}
+	return v
+}
+
+// output 目标语言的译文，lg 为空时返回第一个目标语言
+func (j *Job) output(lg string) (string, []byte, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.status != StatusSucceeded && j.status != StatusPartial {
+		return "", nil, false
+	}
+	if lg == "" {
+		lg = j.langs[0]
+	}
+	buf, ok := j.outputs[lg]
+	if !ok {
+		return "", nil, false
+	}
+	return lg, buf.Bytes(), true
+}
+
+// handle 处理器的事件回调，更新进度并发布给订阅者
+func (j *Job) handle(e eden.Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.total = e.Total
+	j.done = e.Done
+	j.failed = e.Failed
+	if e.Type == eden.EventSaved {
+		if buf, ok := j.pending[e.Lang]; ok {
+			j.outputs[e.Lang] = buf
+			delete(j.pending, e.Lang)
+		}
+	}
+	j.publish(msgProgress, e)
+}
+
+// writer 按目标语言创建译文缓冲区，保存成功的事件到达后才能下载
+func (j *Job) writer(to string) (io.Writer, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	buf := &bytes.Buffer{}
+	j.pending[to] = buf
+	return buf, nil
+}
+
+// start 开始处理，任务已取消时返回 false
+func (j *Job) start(now time.Time) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != StatusQueued {
+		return false
+	}
+	j.status = StatusRunning
+	j.started = now
+	return true
+}
+
+// finish 记录处理结果
+func (j *Job) finish(report *eden.Report, err error, now time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.report = report
+	j.err = err
+	j.finished = now
+	j.input = nil
+	j.pending = nil
+	defer func() {
+		j.publish(msgResult, j.view())
+	}()
+	switch {
+	case err != nil && len(j.outputs) == 0:
+		j.status = StatusFailed
+	case err != nil || (report != nil && report.Failed > 0):
+		j.status = StatusPartial
+	default:
+		j.status = StatusSucceeded
+	}
+}
+
+// cancel 取消等待中的任务，err 为取消原因，可以为空，已开始的任务返回 false
+func (j *Job) cancel(now time.Time, err error) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != StatusQueued {
+		return j.status.finished()
+	}
+	j.status = StatusCanceled
+	j.err = err
+	j.finished = now
+	j.input = nil
+	j.publish(msgResult, j.view())
+	return true
+}
+
+// expired 任务结束超过 retention
+func (j *Job) expired(now time.Time, retention time.Duration) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status.finished() && now.Sub(j.finished) > retention
+}
@@ -0,0 +1,531 @@
+// Package server 文档翻译 HTTP 服务，上传文档后由任务队列异步翻译
+//
+// 接口：
+//
+//	POST   /jobs                上传文档（multipart 字段 file），返回任务，webhook 字段为任务结束时的通知地址
+//	GET    /jobs                所有任务
+//	GET    /jobs/{id}           任务状态和进度
+//	GET    /jobs/{id}/download  下载译文，多个目标语言时用 ?lang= 指定
+//	GET    /jobs/{id}/events    以 Server-Sent Events 推送进度、日志和最终结果
+//	DELETE /jobs/{id}           删除任务，正在翻译的任务不能删除
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gou-jjjj/eden"
+	"github.com/gou-jjjj/eden/config"
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/translate"
+	"github.com/panjf2000/ants"
+)
+
+const (
+	// DefaultRetention 任务结束后保留的时间
+	DefaultRetention = time.Hour
+	// DefaultMaxUpload 上传文件的最大字节数
+	DefaultMaxUpload = 32 << 20
+	// DefaultQueueSize 等待处理的最大任务数
+	DefaultQueueSize = 100
+	// DefaultEventHistory 每个任务保留的事件流消息数
+	DefaultEventHistory = 10000
+)
+
+// ErrQueueFull 等待处理的任务过多
+var ErrQueueFull = errors.New("job queue is full")
+
+// ErrServerClosed 服务已关闭，不再接受任务，队列中未开始的任务以该错误取消
+var ErrServerClosed = errors.New("server is closed")
+
+// Option 服务选项
+type Option func(*Server)
+
+// WithWorkers 同时处理的任务数
+func WithWorkers(n int) Option {
+	return func(s *Server) {
+		s.workers = n
+	}
+}
+
+// WithMaxGo 所有任务共享的翻译并发数
+func WithMaxGo(n int) Option {
+	return func(s *Server) {
+		s.maxGo = n
+	}
+}
+
+// WithQueueSize 等待处理的最大任务数，队列满时拒绝上传
+func WithQueueSize(n int) Option {
+	return func(s *Server) {
+		s.queueSize = n
+	}
+}
+
+// WithEventHistory 每个任务保留的事件流消息数，更早的消息被丢弃，重连的客户端会收到 gap 消息
+func WithEventHistory(n int) Option {
+	return func(s *Server) {
+		s.history = n
+	}
+}
+
+// WithRetention 任务结束后保留的时间，过期后删除任务和译文
+func WithRetention(d time.Duration) Option {
+	return func(s *Server) {
+		s.retention = d
+	}
+}
+
+// WithMaxUpload 上传文件的最大字节数
+func WithMaxUpload(n int64) Option {
+	return func(s *Server) {
+		s.maxUpload = n
+	}
+}
+
+// WithDefaults 任务的默认配置，上传时可以覆盖语言和翻译器
+func WithDefaults(job *config.Job) Option {
+	return func(s *Server) {
+		s.defaults = job
+	}
+}
+
+// Server 翻译服务
+type Server struct {
+	workers   int
+	maxGo     int
+	queueSize int
+	history   int
+	retention time.Duration
+	maxUpload int64
+	defaults  *config.Job
+
+	webhookSecret []byte
+	webhookRetry  translate.RetryConfig
+	webhookClient *http.Client
+	webhookAllow  map[string]bool
+	baseURL       string
+
+	mu    sync.RWMutex
+	jobs  map[string]*Job
+	queue chan *Job
+	pool  *ants.Pool
+	mux   *http.ServeMux
+	now   func() time.Time
+
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// New 创建并启动服务，使用完毕后调用 Close
+func New(opts ...Option) *Server {
+	s := &Server{
+		webhookRetry: DefaultWebhookRetry,
+		webhookAllow: map[string]bool{},
+		jobs:         map[string]*Job{},
+		now:          time.Now,
+		stop:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+
+	if s.webhookClient == nil {
+		s.webhookClient = s.newWebhookClient()
+	}
+	if s.workers <= 0 {
+		s.workers = 1
+	}
+	if s.maxGo <= 0 {
+		s.maxGo = 4
+	}
+	if s.queueSize <= 0 {
+		s.queueSize = DefaultQueueSize
+	}
+	if s.history <= 0 {
+		s.history = DefaultEventHistory
+	}
+	if s.retention <= 0 {
+		s.retention = DefaultRetention
+	}
+	if s.maxUpload <= 0 {
+		s.maxUpload = DefaultMaxUpload
+	}
+	if s.defaults == nil {
+		s.defaults = &config.Job{}
+	}
+
+	s.queue = make(chan *Job, s.queueSize)
+	s.pool, _ = ants.NewPool(s.maxGo,
+		ants.WithMaxBlockingTasks(1<<20),
+		ants.WithPreAlloc(true),
+		ants.WithExpiryDuration(1))
+
+	s.mux = http.NewServeMux()
+	s.routes()
+
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+	s.wg.Add(1)
+	go s.cleanupLoop()
+	return s
+}
+
+// routes 注册接口
+func (s *Server) routes() {
+	s.mux.HandleFunc("POST /jobs", s.handleCreate)
+	s.mux.HandleFunc("GET /jobs", s.handleList)
+	s.mux.HandleFunc("GET /jobs/{id}", s.handleGet)
+	s.mux.HandleFunc("GET /jobs/{id}/download", s.handleDownload)
+	s.mux.HandleFunc("GET /jobs/{id}/events", s.handleEvents)
+	s.mux.HandleFunc("DELETE /jobs/{id}", s.handleDelete)
+}
+
+// ServeHTTP 实现 http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close 停止接收任务，等待正在处理的任务结束
+func (s *Server) Close() error {
+	s.stopOnce.Do(func() {
+		// 持有锁关闭，之后提交的任务不会再进入队列
+		s.mu.Lock()
+		close(s.stop)
+		s.mu.Unlock()
+		s.wg.Wait()
+		s.pool.Release()
+
+		// 队列中还没开始的任务不会再处理
+		for {
+			select {
+			case job := <-s.queue:
+				job.cancel(s.now(), ErrServerClosed)
+			default:
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// Submit 提交任务，cfg 为空时使用默认配置
+func (s *Server) Submit(name string, input []byte, cfg *config.Job, opts ...JobOpt) (*Job, error) {
+	if cfg == nil {
+		cfg = s.defaults
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	job := newJob(name, input, cfg, s.now())
+	job.limit = s.history
+	for _, opt := range opts {
+		if opt != nil {
+			opt(job)
+		}
+	}
+	if job.webhook != "" {
+		if err := s.validWebhook(job.webhook); err != nil {
+			return nil, err
+		}
+	}
+	// 先保存任务再放入队列，工作协程取到任务时已经可以查询
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.stop:
+		return nil, ErrServerClosed
+	default:
+	}
+	s.jobs[job.id] = job
+	select {
+	case s.queue <- job:
+	default:
+		delete(s.jobs, job.id)
+		return nil, ErrQueueFull
+	}
+	return job, nil
+}
+
+// Job 按 ID 获取任务
+func (s *Server) Job(id string) (*Job, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	return job, ok
+}
+
+// work 从队列中取出任务并处理
+func (s *Server) work() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case job := <-s.queue:
+			// 关闭和队列同时就绪时 select 随机选择，关闭后取到的任务不再开始
+			select {
+			case <-s.stop:
+				job.cancel(s.now(), ErrServerClosed)
+				return
+			default:
+			}
+			s.run(job)
+		}
+	}
+}
+
+// run 在内存中处理任务
+func (s *Server) run(job *Job) {
+	if !job.start(s.now()) {
+		return
+	}
+
+	opts, err := job.config.Options()
+	if err != nil {
+		job.finish(nil, err, s.now())
+		return
+	}
+	name := strings.TrimSuffix(job.name, filepath.Ext(job.name))
+	opts = append(opts,
+		eden.WithBytes(job.input),
+		eden.WithName(name),
+		eden.WithJobID(job.id),
+		eden.WithTargetWriter(job.writer),
+		eden.WithLogWriter(logWriter{job}),
+		eden.WithPool(s.pool),
+		eden.WithEventHandler(job.handle))
+
+	report, err := eden.NewDocxProcessor(opts...).Process()
+	job.finish(report, err, s.now())
+	if job.webhook != "" {
+		s.wg.Add(1)
+		go s.notify(job)
+	}
+}
+
+// cleanupLoop 定期删除过期任务
+func (s *Server) cleanupLoop() {
+	defer s.wg.Done()
+	interval := s.retention / 10
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.cleanup()
+		}
+	}
+}
+
+// cleanup 删除结束超过保留时间的任务，返回删除的数量
+func (s *Server) cleanup() int {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for id, job := range s.jobs {
+		if job.expired(now, s.retention) {
+			delete(s.jobs, id)
+			n++
+		}
+	}
+	return n
+}
+
+// jobConfig 上传表单中的参数覆盖默认配置
+func (s *Server) jobConfig(r *http.Request) (*config.Job, error) {
+	cfg := *s.defaults
+	if v := r.FormValue("provider"); v != "" {
+		cfg.Provider = v
+	}
+	if v := r.FormValue("from"); v != "" {
+		cfg.From = v
+	}
+	if v := r.FormValue("to"); v != "" {
+		cfg.To = config.SplitList(v)
+	}
+	if v := r.FormValue("max_token"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, &config.Error{Key: "max_token", Msg: err.Error()}
+		}
+		cfg.MaxToken = n
+	}
+	if v := r.FormValue("strict"); v != "" {
+		strict, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, &config.Error{Key: "strict", Msg: err.Error()}
+		}
+		cfg.Strict = strict
+	}
+	for _, f := range []struct {
+		key   string
+		value *string
+	}{
+		{"domain", &cfg.Domain},
+		{"tone", &cfg.Tone},
+		{"formality", &cfg.Formality},
+		{"audience", &cfg.Audience},
+	} {
+		if v := r.FormValue(f.key); v != "" {
+			*f.value = v
+		}
+	}
+	if v := r.FormValue("locale"); v != "" {
+		cfg.Locale = config.SplitList(v)
+	}
+	// 内存中处理，不使用输出目录和检查点
+	cfg.Output = ""
+	cfg.Checkpoint = ""
+
+	if cfg.Provider == "" {
+		return nil, &config.Error{Key: "provider", Msg: "provider is required"}
+	}
+	return &cfg, cfg.Validate()
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxUpload)
+	if err := r.ParseMultipartForm(s.maxUpload); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid upload: %w", err))
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("file is required: %w", err))
+		return
+	}
+	defer file.Close()
+
+	input, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	cfg, err := s.jobConfig(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	job, err := s.Submit(filepath.Base(header.Filename), input, cfg, WithWebhook(r.FormValue("webhook")))
+	switch {
+	case errors.Is(err, ErrQueueFull), errors.Is(err, ErrServerClosed):
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Location", "/jobs/"+job.id)
+	writeJSON(w, http.StatusAccepted, job.View())
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	views := make([]JobView, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		views = append(views, job.View())
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(views, func(i, j int) bool {
+		return views[i].Created.Before(views[j].Created)
+	})
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.Job(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("job not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, job.View())
+}
+
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.Job(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("job not found"))
+		return
+	}
+
+	lg := ""
+	if v := r.URL.Query().Get("lang"); v != "" {
+		l, err := lang.Lookup(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		lg = l
+	}
+
+	to, data, ok := job.output(lg)
+	if !ok {
+		writeError(w, http.StatusConflict, fmt.Errorf("no output available, job is %s", job.Status()))
+		return
+	}
+
+	name := strings.TrimSuffix(job.name, filepath.Ext(job.name))
+	filename := fmt.Sprintf("%s_%s.docx", name, to)
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	http.ServeContent(w, r, filename, job.View().Created, bytes.NewReader(data))
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.Job(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("job not found"))
+		return
+	}
+	if !job.cancel(s.now(), nil) {
+		writeError(w, http.StatusConflict, errors.New("job is running"))
+		return
+	}
+
+	s.mu.Lock()
+	delete(s.jobs, job.id)
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON 返回 JSON
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError 返回 JSON 格式的错误
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
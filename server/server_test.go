@@ -0,0 +1,253 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gou-jjjj/eden"
+	"github.com/gou-jjjj/eden/config"
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/translate"
+)
+
+// upload 构造上传请求
+func upload(t *testing.T, ts *httptest.Server, data []byte, fields map[string]string) *http.Response {
+	t.Helper()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, err := mw.CreateFormFile("file", "doc.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(data)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	mw.Close()
+
+	resp, err := http.Post(ts.URL+"/jobs", mw.FormDataContentType(), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// waitJob 等待任务结束
+func waitJob(t *testing.T, ts *httptest.Server, id string) JobView {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(ts.URL + "/jobs/" + id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v JobView
+		json.NewDecoder(resp.Body).Decode(&v)
+		resp.Body.Close()
+		if v.Status.finished() {
+			return v
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return JobView{}
+}
+
+func TestServer_Jobs(t *testing.T) {
+	src, err := os.ReadFile("../file_examples/Docx4j_GettingStarted.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(WithWorkers(2), WithDefaults(&config.Job{Provider: translate.Mock}))
+	defer s.Close()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp := upload(t, ts, src, map[string]string{"to": "zh,ja"})
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	var created JobView
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if created.ID == "" || resp.Header.Get("Location") != "/jobs/"+created.ID {
+		t.Fatalf("created = %+v, location = %q", created, resp.Header.Get("Location"))
+	}
+
+	v := waitJob(t, ts, created.ID)
+	if v.Status != StatusSucceeded {
+		t.Fatalf("status = %s, error = %s", v.Status, v.Error)
+	}
+	if len(v.Outputs) != 2 || v.Outputs[0] != lang.ZH || v.Outputs[1] != lang.JA {
+		t.Errorf("outputs = %v", v.Outputs)
+	}
+	if v.Total == 0 || v.Done != v.Total {
+		t.Errorf("progress = %d/%d", v.Done, v.Total)
+	}
+
+	for _, lg := range []string{"", "ja"} {
+		resp, err := http.Get(ts.URL + "/jobs/" + created.ID + "/download?lang=" + lg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := &bytes.Buffer{}
+		data.ReadFrom(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || data.Len() == 0 {
+			t.Errorf("download %q: status = %d, size = %d", lg, resp.StatusCode, data.Len())
+		}
+	}
+
+	resp, _ = http.Get(ts.URL + "/jobs/" + created.ID + "/download?lang=ko")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("download ko: status = %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/jobs/"+created.ID, nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status = %d", resp.StatusCode)
+	}
+	resp, _ = http.Get(ts.URL + "/jobs/" + created.ID)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("get deleted: status = %d", resp.StatusCode)
+	}
+}
+
+func TestServer_Invalid(t *testing.T) {
+	s := New(WithDefaults(&config.Job{Provider: translate.Mock}))
+	defer s.Close()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	cases := []map[string]string{
+		{"to": "xx"},
+		{"provider": "nope"},
+		{"strict": "maybe"},
+		{"formality": "casual"},
+		{"to": "zh", "locale": "en-GB"},
+	}
+	for _, fields := range cases {
+		resp := upload(t, ts, []byte("x"), fields)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%v: status = %d", fields, resp.StatusCode)
+		}
+	}
+
+	resp := upload(t, ts, []byte("not a docx"), nil)
+	var created JobView
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if v := waitJob(t, ts, created.ID); v.Status != StatusFailed || v.Error == "" {
+		t.Errorf("status = %s, error = %q", v.Status, v.Error)
+	}
+}
+
+func TestServer_Cleanup(t *testing.T) {
+	now := time.Now()
+	s := New(WithRetention(time.Minute), WithDefaults(&config.Job{Provider: translate.Mock}))
+	defer s.Close()
+	s.now = func() time.Time { return now }
+
+	job, err := s.Submit("a.docx", []byte("x"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for !job.Status().finished() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if n := s.cleanup(); n != 0 {
+		t.Fatalf("cleanup before retention = %d", n)
+	}
+	now = now.Add(2 * time.Minute)
+	if n := s.cleanup(); n != 1 {
+		t.Fatalf("cleanup after retention = %d", n)
+	}
+	if _, ok := s.Job(job.ID()); ok {
+		t.Error("expired job still present")
+	}
+}
+
+func TestServer_CloseCancelsQueued(t *testing.T) {
+	s := New(WithWorkers(1), WithQueueSize(2), WithDefaults(&config.Job{Provider: translate.Mock}))
+	src, err := os.ReadFile("../file_examples/Docx4j_GettingStarted.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := make([]*Job, 0)
+	for {
+		job, err := s.Submit("a.docx", src, nil)
+		if errors.Is(err, ErrQueueFull) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, job)
+	}
+	if len(s.jobs) != len(jobs) {
+		t.Errorf("Expected rejected jobs not to be stored, got %d for %d", len(s.jobs), len(jobs))
+	}
+
+	s.Close()
+	canceled := 0
+	for _, job := range jobs {
+		v := job.View()
+		if !v.Status.finished() {
+			t.Errorf("Job %s left in %s after Close", v.ID, v.Status)
+		}
+		if v.Status == StatusCanceled {
+			canceled++
+			if v.Error != ErrServerClosed.Error() {
+				t.Errorf("Unexpected cancel error %q", v.Error)
+			}
+		}
+	}
+	if canceled == 0 {
+		t.Error("Expected queued jobs to be canceled")
+	}
+	if _, err := s.Submit("a.docx", src, nil); !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Expected ErrServerClosed after Close, got %v", err)
+	}
+}
+
+func TestJob_OutputAfterSave(t *testing.T) {
+	now := time.Now()
+	cfg := &config.Job{Provider: translate.Mock, To: []string{"zh"}}
+
+	// 保存失败时写了一半的缓冲区不能下载
+	job := newJob("a.docx", nil, cfg, now)
+	w, _ := job.writer(lang.ZH)
+	w.Write([]byte("partial"))
+	job.finish(nil, errors.New("save failed"), now)
+	if job.Status() != StatusFailed {
+		t.Errorf("status = %s, want %s", job.Status(), StatusFailed)
+	}
+	if _, _, ok := job.output(lang.ZH); ok {
+		t.Error("Expected no output after failed save")
+	}
+
+	job = newJob("a.docx", nil, cfg, now)
+	w, _ = job.writer(lang.ZH)
+	w.Write([]byte("done"))
+	job.handle(eden.Event{Type: eden.EventSaved, Lang: lang.ZH})
+	job.finish(&eden.Report{}, nil, now)
+	if _, data, ok := job.output(lang.ZH); !ok || string(data) != "done" {
+		t.Errorf("output = %q, %v", data, ok)
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 事件流中的消息类型
+const (
+	msgProgress = "progress" // 处理器的进度事件，数据为 eden.Event
+	msgLog      = "log"      // 一行日志，数据为 logLine
+	msgResult   = "result"   // 任务结束，数据为 JobView，之后关闭事件流
+	msgGap      = "gap"      // Last-Event-ID 之后的部分消息已被丢弃，数据为 gapInfo
+)
+
+// heartbeat 事件流空闲时发送注释行的间隔，防止代理断开连接
+var heartbeat = 15 * time.Second
+
+// message 事件流中的一条消息，id 为在任务中的序号，从 1 开始
+type message struct {
+	id    int
+	event string
+	data  []byte
+}
+
+// logLine 日志消息的数据
+type logLine struct {
+	Line string `json:"line"`
+}
+
+// gapInfo gap 消息的数据，附带任务当前的状态，客户端据此恢复进度
+type gapInfo struct {
+	Missed int     `json:"missed"` // 丢弃的消息数
+	Job    JobView `json:"job"`
+}
+
+// publish 发布消息并唤醒订阅者，只保留最近 limit 条消息，调用方需要持有锁
+func (j *Job) publish(event string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	j.seq++
+	m := message{id: j.seq, event: event, data: data}
+	if len(j.history) < j.limit {
+		j.history = append(j.history, m)
+	} else {
+		j.history[(j.seq-1)%j.limit] = m
+	}
+	close(j.notify)
+	j.notify = make(chan struct{})
+}
+
+// messages 序号大于 after 的消息，以及新消息到达时关闭的 channel，任务结束后 channel 为 nil
+// 其中部分消息已被丢弃时，先返回一条序号为最早保留消息之前的 gap 消息
+func (j *Job) messages(after int) ([]message, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var msgs []message
+	if oldest := j.seq - len(j.history) + 1; after < oldest-1 {
+		data, _ := json.Marshal(gapInfo{Missed: oldest - 1 - after, Job: j.view()})
+		msgs = append(msgs, message{id: oldest - 1, event: msgGap, data: data})
+		after = oldest - 1
+	}
+	for id := after + 1; id <= j.seq; id++ {
+		msgs = append(msgs, j.history[(id-1)%j.limit])
+	}
+	if j.status.finished() {
+		return msgs, nil
+	}
+	return msgs, j.notify
+}
+
+// lastEventID 最后一条消息的 ID
+func (j *Job) lastEventID() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.seq
+}
+
+// logWriter 按行发布日志，同时作为处理器的日志输出
+type logWriter struct {
+	j *Job
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	j := w.j
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.logs.Write(p)
+	for {
+		line, err := j.logs.ReadString('\n')
+		if err != nil {
+			// 未写完的行放回缓冲区
+			j.logs.WriteString(line)
+			break
+		}
+		j.publish(msgLog, logLine{Line: string(bytes.TrimRight([]byte(line), "\r\n"))})
+	}
+	return len(p), nil
+}
+
+// handleEvents 以 Server-Sent Events 推送任务的进度和日志，任务结束时发送结果并关闭
+// 重连时根据 Last-Event-ID 从断开处继续，断开期间的消息已被丢弃时先发送 gap 消息
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.Job(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("job not found"))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	after := 0
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid Last-Event-ID %q", v))
+			return
+		}
+		// 超过已发布的消息时之后的消息都会被跳过
+		if last := job.lastEventID(); n > last {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("Last-Event-ID %d is after the last event %d", n, last))
+			return
+		}
+		after = n
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		msgs, notify := job.messages(after)
+		for _, m := range msgs {
+			if err := writeMessage(w, m); err != nil {
+				return
+			}
+			after = m.id
+		}
+		flusher.Flush()
+		if notify == nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-notify:
+		}
+	}
+}
+
+// writeMessage 按 SSE 格式写出一条消息
+func writeMessage(w io.Writer, m message) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", m.id, m.event, m.data)
+	return err
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gou-jjjj/eden/config"
+	"github.com/gou-jjjj/eden/translate"
+)
+
+// sseMessage 解析出的一条 SSE 消息
+type sseMessage struct {
+	id    int
+	event string
+	data  string
+}
+
+// readEvents 读取事件流直到服务端关闭
+func readEvents(t *testing.T, url, lastID string) []sseMessage {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	msgs := make([]sseMessage, 0)
+	cur := sseMessage{}
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(nil, 16<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if cur.event != "" {
+				msgs = append(msgs, cur)
+			}
+			cur = sseMessage{}
+		case strings.HasPrefix(line, "id: "):
+			cur.id, _ = strconv.Atoi(line[len("id: "):])
+		case strings.HasPrefix(line, "event: "):
+			cur.event = line[len("event: "):]
+		case strings.HasPrefix(line, "data: "):
+			cur.data = line[len("data: "):]
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return msgs
+}
+
+func TestServer_Events(t *testing.T) {
+	src, err := os.ReadFile("../file_examples/Docx4j_GettingStarted.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(WithDefaults(&config.Job{Provider: translate.Mock, To: []string{"zh"}}))
+	defer s.Close()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	job, err := s.Submit("doc.docx", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := ts.URL + "/jobs/" + job.ID() + "/events"
+	msgs := readEvents(t, url, "")
+
+	count := map[string]int{}
+	for i, m := range msgs {
+		count[m.event]++
+		if m.id != i+1 {
+			t.Fatalf("message %d has id %d", i, m.id)
+		}
+	}
+	if count[msgProgress] == 0 || count[msgLog] == 0 || count[msgResult] != 1 {
+		t.Fatalf("Unexpected messages %v", count)
+	}
+
+	last := msgs[len(msgs)-1]
+	if last.event != msgResult {
+		t.Fatalf("last message is %q", last.event)
+	}
+	var v JobView
+	if err := json.Unmarshal([]byte(last.data), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Status != StatusSucceeded || v.Done != v.Total || v.Total == 0 {
+		t.Errorf("Unexpected result %+v", v)
+	}
+
+	// 从断开处继续只返回之后的消息
+	rest := readEvents(t, url, strconv.Itoa(len(msgs)-2))
+	if len(rest) != 2 || rest[1].event != msgResult {
+		t.Errorf("Unexpected resumed messages %+v", rest)
+	}
+
+	// 超过最后一条消息的 ID 会跳过之后的所有消息
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Last-Event-ID", strconv.Itoa(len(msgs)+1))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Last-Event-ID after the last event: status = %d", resp.StatusCode)
+	}
+
+	resp, _ = http.Get(ts.URL + "/jobs/missing/events")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing job: status = %d", resp.StatusCode)
+	}
+}
+
+func TestServer_EventsHistory(t *testing.T) {
+	src, err := os.ReadFile("../file_examples/Docx4j_GettingStarted.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const limit = 5
+	s := New(WithDefaults(&config.Job{Provider: translate.Mock, To: []string{"zh"}}), WithEventHistory(limit))
+	defer s.Close()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	job, err := s.Submit("doc.docx", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := ts.URL + "/jobs/" + job.ID() + "/events"
+	readEvents(t, url, "")
+
+	job.mu.Lock()
+	seq, kept := job.seq, len(job.history)
+	job.mu.Unlock()
+	if kept != limit || seq <= limit {
+		t.Fatalf("kept %d of %d messages, want %d", kept, seq, limit)
+	}
+
+	// 丢弃的消息以 gap 消息代替，附带任务当前的状态
+	msgs := readEvents(t, url, "")
+	if len(msgs) != limit+1 || msgs[0].event != msgGap || msgs[0].id != seq-limit {
+		t.Fatalf("Unexpected messages %+v", msgs)
+	}
+	var gap gapInfo
+	if err := json.Unmarshal([]byte(msgs[0].data), &gap); err != nil {
+		t.Fatal(err)
+	}
+	if gap.Missed != seq-limit || gap.Job.Status != StatusSucceeded {
+		t.Errorf("Unexpected gap %+v", gap)
+	}
+	for i, m := range msgs[1:] {
+		if m.id != seq-limit+1+i {
+			t.Errorf("message %d has id %d", i, m.id)
+		}
+	}
+	if msgs[limit].event != msgResult {
+		t.Errorf("last message is %q", msgs[limit].event)
+	}
+
+	// 从保留范围内继续时没有 gap
+	rest := readEvents(t, url, strconv.Itoa(seq-2))
+	if len(rest) != 2 || rest[0].event == msgGap {
+		t.Errorf("Unexpected resumed messages %+v", rest)
+	}
+}
@@ -0,0 +1,404 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gou-jjjj/eden/translate"
+)
+
+const (
+	// SignatureHeader 时间戳和请求体的 HMAC-SHA256 签名，格式为 sha256=<hex>，见 Sign
+	SignatureHeader = "X-Eden-Signature"
+	// TimestampHeader 发送时间的 Unix 秒数，与请求体一起签名，接收方据此拒绝重放的旧请求
+	TimestampHeader = "X-Eden-Timestamp"
+	// EventHeader 通知的事件类型
+	EventHeader = "X-Eden-Event"
+	// DeliveryHeader 通知 ID，重试时不变，接收方可以用来去重
+	DeliveryHeader = "X-Eden-Delivery"
+
+	// EventJobFinished 任务结束通知，包括成功、部分失败和失败
+	EventJobFinished = "job.finished"
+
+	// DefaultSignatureTolerance 校验签名时允许的时间戳误差
+	DefaultSignatureTolerance = 5 * time.Minute
+)
+
+// DefaultWebhookRetry 通知失败后的默认重试配置
+var DefaultWebhookRetry = translate.RetryConfig{
+	MaxRetries:    5,
+	BaseDelay:     time.Second,
+	MaxDelay:      time.Minute,
+	BackoffFactor: 2.0,
+	Jitter:        0.2,
+}
+
+// JobOpt 任务选项
+type JobOpt func(*Job)
+
+// WithWebhook 任务结束时向 url 发送通知
+func WithWebhook(url string) JobOpt {
+	return func(j *Job) {
+		j.webhook = url
+	}
+}
+
+// WithWebhookSecret 通知签名使用的密钥，不设置时不签名
+func WithWebhookSecret(secret string) Option {
+	return func(s *Server) {
+		s.webhookSecret = []byte(secret)
+	}
+}
+
+// WithWebhookRetry 通知失败后的重试配置
+func WithWebhookRetry(cfg translate.RetryConfig) Option {
+	return func(s *Server) {
+		s.webhookRetry = cfg
+	}
+}
+
+// WithWebhookAllowlist 只向这些主机发送通知，列表中的主机可以是内网地址
+// 不设置时允许任意主机，但拒绝解析到回环、私有和链路本地地址的主机
+func WithWebhookAllowlist(hosts ...string) Option {
+	return func(s *Server) {
+		for _, h := range hosts {
+			if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+				s.webhookAllow[h] = true
+			}
+		}
+	}
+}
+
+// WithWebhookClient 发送通知使用的 HTTP 客户端
+// 默认客户端在连接时检查解析出的地址，自定义客户端只在提交任务时检查通知地址
+func WithWebhookClient(client *http.Client) Option {
+	return func(s *Server) {
+		s.webhookClient = client
+	}
+}
+
+// WithBaseURL 服务的外部地址，用于生成通知中的下载地址，必须是 http 或 https 的绝对地址
+// 不设置时不接受带通知地址的任务
+func WithBaseURL(base string) Option {
+	return func(s *Server) {
+		s.baseURL = strings.TrimRight(base, "/")
+	}
+}
+
+// WebhookPayload 任务结束通知的内容
+type WebhookPayload struct {
+	Event      string                 `json:"event"`
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Status     JobStatus              `json:"status"`
+	Error      string                 `json:"error,omitempty"`
+	Created    time.Time              `json:"created"`
+	Finished   time.Time              `json:"finished"`
+	Outputs    []WebhookOutput        `json:"outputs,omitempty"`
+	Total      int                    `json:"total"`      // 分块总数
+	Translated int                    `json:"translated"` // 翻译成功的分块数
+	Skipped    int                    `json:"skipped"`    // 跳过的分块数
+	Failed     int                    `json:"failed"`     // 翻译失败的分块数
+	Usage      translate.UsageByModel `json:"usage,omitempty"`
+	Cost       float64                `json:"cost"`
+	Duration   time.Duration          `json:"duration"`
+}
+
+// WebhookOutput 一种目标语言译文的下载地址
+type WebhookOutput struct {
+	Lang string `json:"lang"`
+	URL  string `json:"url"`
+}
+
+// Delivery 一次通知请求的记录
+type Delivery struct {
+	ID         string        `json:"id"`
+	Attempt    int           `json:"attempt"` // 第几次请求，从 1 开始
+	Time       time.Time     `json:"time"`
+	Duration   time.Duration `json:"duration"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// Sign 计算时间戳和请求体的签名，签名内容为 "<timestamp>.<body>"，格式与 SignatureHeader 相同
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature 校验通知签名和时间戳，供接收方使用
+// 时间戳与当前时间相差超过 tolerance 时校验失败，tolerance 不大于 0 时使用 DefaultSignatureTolerance
+func VerifySignature(secret, body []byte, timestamp, signature string, tolerance time.Duration) bool {
+	if tolerance <= 0 {
+		tolerance = DefaultSignatureTolerance
+	}
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := time.Since(time.Unix(sec, 0)); d > tolerance || d < -tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// validWebhook 检查通知地址是否为 http 或 https 的绝对地址，主机在允许列表中，
+// 没有允许列表时不能是 localhost 或回环、私有和链路本地地址
+func (s *Server) validWebhook(raw string) error {
+	if err := validBaseURL(s.baseURL); err != nil {
+		return fmt.Errorf("webhooks require the external URL of the service for download links: %w", err)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid webhook: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook %q: must be an absolute http or https URL", raw)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if len(s.webhookAllow) > 0 {
+		if !s.webhookAllow[host] {
+			return fmt.Errorf("invalid webhook %q: host %s is not allowed", raw, host)
+		}
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("invalid webhook %q: local hosts are not allowed", raw)
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return fmt.Errorf("invalid webhook %q: address %s is not public", raw, ip)
+	}
+	return nil
+}
+
+// validBaseURL 检查服务的外部地址是否为 http 或 https 的绝对地址
+func validBaseURL(base string) error {
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("base URL %q must be an absolute http or https URL", base)
+	}
+	return nil
+}
+
+// publicIP 地址不是回环、私有、链路本地、组播或未指定地址
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// dialWebhook 默认客户端的连接函数，不在允许列表中的主机解析后检查所有地址，
+// 直接连接检查过的地址，避免解析结果在检查后被替换
+func (s *Server) dialWebhook(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if s.webhookAllow[strings.ToLower(host)] {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("webhook host %s has no address", host)
+	}
+	for _, ip := range ips {
+		if !publicIP(ip.IP) {
+			return nil, fmt.Errorf("webhook host %s resolves to non-public address %s", host, ip.IP)
+		}
+	}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+}
+
+// newWebhookClient 发送通知的默认客户端，不使用代理，连接时检查地址
+func (s *Server) newWebhookClient() *http.Client {
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: s.dialWebhook},
+	}
+}
+
+// payload 生成任务结束通知
+func (j *Job) payload(baseURL string) WebhookPayload {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	v := j.view()
+	p := WebhookPayload{
+		Event:    EventJobFinished,
+		ID:       j.id,
+		Name:     j.name,
+		Status:   j.status,
+		Error:    v.Error,
+		Created:  j.created,
+		Finished: j.finished,
+		Total:    j.total,
+		Failed:   j.failed,
+	}
+	for _, l := range v.Outputs {
+		p.Outputs = append(p.Outputs, WebhookOutput{
+			Lang: l,
+			URL:  baseURL + "/jobs/" + j.id + "/download?lang=" + url.QueryEscape(l),
+		})
+	}
+	if r := j.report; r != nil {
+		p.Translated = r.Translated
+		p.Skipped = r.Skipped
+		p.Failed = r.Failed
+		p.Usage = r.Usage
+		p.Cost = r.Cost
+		p.Duration = r.Duration
+	}
+	return p
+}
+
+// addDelivery 记录一次通知请求
+func (j *Job) addDelivery(d Delivery) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.deliveries = append(j.deliveries, d)
+}
+
+// notify 发送任务结束通知，失败时按配置退避重试，服务关闭时放弃
+func (s *Server) notify(job *Job) {
+	defer s.wg.Done()
+
+	body, err := json.Marshal(job.payload(s.baseURL))
+	if err != nil {
+		return
+	}
+	id := newJobID()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	cfg := s.webhookRetry
+	for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
+		d, retryAfter := s.deliver(ctx, job.webhook, id, body)
+		d.Attempt = attempt + 1
+		job.addDelivery(d)
+		if d.StatusCode >= 200 && d.StatusCode < 300 {
+			return
+		}
+		if !retryableDelivery(d) || attempt == cfg.MaxRetries {
+			return
+		}
+
+		timer := time.NewTimer(webhookDelay(cfg, attempt, retryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// deliver 发送一次通知，返回请求记录和服务端要求的重试等待时间
+func (s *Server) deliver(ctx context.Context, target, id string, body []byte) (Delivery, time.Duration) {
+	d := Delivery{ID: id, Time: s.now()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		d.Error = err.Error()
+		return d, 0
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "eden-webhook")
+	req.Header.Set(EventHeader, EventJobFinished)
+	req.Header.Set(DeliveryHeader, id)
+	if len(s.webhookSecret) > 0 {
+		// 每次请求使用新的时间戳，重试的请求不会因为等待而过期
+		timestamp := strconv.FormatInt(d.Time.Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(s.webhookSecret, timestamp, body))
+	}
+
+	start := time.Now()
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		d.Error = err.Error()
+		d.Duration = time.Since(start)
+		return d, 0
+	}
+	resp.Body.Close()
+
+	d.StatusCode = resp.StatusCode
+	d.Duration = time.Since(start)
+	if resp.StatusCode >= 300 {
+		d.Error = resp.Status
+	}
+	return d, parseRetryAfter(resp.Header.Get("Retry-After"), s.now())
+}
+
+// webhookDelay 第 attempt 次重试前的等待时间，服务端要求的等待时间优先，同样不超过最大退避时间
+func webhookDelay(cfg translate.RetryConfig, attempt int, retryAfter time.Duration) time.Duration {
+	delay := float64(retryAfter)
+	if retryAfter <= 0 {
+		delay = float64(cfg.BaseDelay) * math.Pow(cfg.BackoffFactor, float64(attempt))
+		if cfg.Jitter > 0 {
+			delay *= 1 + cfg.Jitter*(rand.Float64()*2-1)
+		}
+	}
+	if cfg.MaxDelay > 0 && delay > float64(cfg.MaxDelay) {
+		delay = float64(cfg.MaxDelay)
+	}
+	return time.Duration(delay)
+}
+
+// parseRetryAfter 解析 Retry-After 头，支持秒数和 HTTP 日期两种格式
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// retryableDelivery 网络错误、超时、限流和服务端错误可以重试，其他客户端错误不重试
+func retryableDelivery(d Delivery) bool {
+	switch {
+	case d.StatusCode == 0:
+		return true
+	case d.StatusCode == http.StatusRequestTimeout, d.StatusCode == http.StatusTooManyRequests:
+		return true
+	default:
+		return d.StatusCode >= 500
+	}
+}
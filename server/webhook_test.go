@@ -0,0 +1,240 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gou-jjjj/eden/config"
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/translate"
+)
+
+// waitDeliveries 等待通知记录达到 n 条
+func waitDeliveries(t *testing.T, job *Job, n int) []Delivery {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		if d := job.View().Deliveries; len(d) >= n {
+			return d
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d deliveries, got %v", n, job.View().Deliveries)
+	return nil
+}
+
+func TestServer_Webhook(t *testing.T) {
+	src, err := os.ReadFile("../file_examples/Docx4j_GettingStarted.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("s3cret")
+
+	var (
+		mu       sync.Mutex
+		calls    int
+		payloads []WebhookPayload
+		ids      []string
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !VerifySignature(secret, body, r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), 0) {
+			t.Errorf("invalid signature %q", r.Header.Get(SignatureHeader))
+		}
+		if r.Header.Get(EventHeader) != EventJobFinished {
+			t.Errorf("%s = %q", EventHeader, r.Header.Get(EventHeader))
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		ids = append(ids, r.Header.Get(DeliveryHeader))
+		if calls <= 2 {
+			// 服务端要求的等待时间按最大退避时间截断
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var p WebhookPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Error(err)
+		}
+		payloads = append(payloads, p)
+	}))
+	defer receiver.Close()
+
+	s := New(
+		WithDefaults(&config.Job{Provider: translate.Mock, To: []string{"zh"}}),
+		WithWebhookSecret(string(secret)),
+		WithWebhookRetry(translate.RetryConfig{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond, BackoffFactor: 2}),
+		WithWebhookAllowlist("127.0.0.1"),
+		WithBaseURL("https://eden.example.com/"))
+	defer s.Close()
+
+	job, err := s.Submit("doc.docx", src, nil, WithWebhook(receiver.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	deliveries := waitDeliveries(t, job, 3)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Retry-After was not clamped to the max delay, took %v", elapsed)
+	}
+
+	if len(deliveries) != 3 {
+		t.Fatalf("deliveries = %+v", deliveries)
+	}
+	for i, d := range deliveries {
+		if d.Attempt != i+1 || d.ID != deliveries[0].ID {
+			t.Errorf("delivery %d = %+v", i, d)
+		}
+	}
+	if deliveries[0].StatusCode != http.StatusServiceUnavailable || deliveries[2].StatusCode != http.StatusOK {
+		t.Errorf("Unexpected status codes %+v", deliveries)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if ids[0] != ids[2] || ids[0] != deliveries[0].ID {
+		t.Errorf("delivery ids = %v", ids)
+	}
+	if len(payloads) != 1 {
+		t.Fatalf("payloads = %+v", payloads)
+	}
+	p := payloads[0]
+	if p.ID != job.ID() || p.Status != StatusSucceeded || p.Translated == 0 || p.Total == 0 {
+		t.Errorf("Unexpected payload %+v", p)
+	}
+	want := "https://eden.example.com/jobs/" + job.ID() + "/download?lang=" + lang.ZH
+	if len(p.Outputs) != 1 || p.Outputs[0].URL != want {
+		t.Errorf("outputs = %+v, want %s", p.Outputs, want)
+	}
+}
+
+func TestServer_WebhookNoRetry(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(SignatureHeader) != "" || r.Header.Get(TimestampHeader) != "" {
+			t.Error("unexpected signature without a secret")
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer receiver.Close()
+
+	s := New(WithDefaults(&config.Job{Provider: translate.Mock}),
+		WithWebhookRetry(translate.RetryConfig{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}),
+		WithWebhookAllowlist("127.0.0.1"),
+		WithBaseURL("http://localhost:8080"))
+	defer s.Close()
+
+	job, err := s.Submit("bad.docx", []byte("not a docx"), nil, WithWebhook(receiver.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitDeliveries(t, job, 1)
+	time.Sleep(50 * time.Millisecond)
+	if d := job.View().Deliveries; len(d) != 1 || d[0].StatusCode != http.StatusBadRequest {
+		t.Errorf("deliveries = %+v", d)
+	}
+
+	if _, err := s.Submit("a.docx", []byte("x"), nil, WithWebhook("ftp://example.com")); err == nil {
+		t.Error("expected an error for a non-http webhook")
+	}
+}
+
+func TestServer_WebhookValidation(t *testing.T) {
+	cfg := &config.Job{Provider: translate.Mock}
+	tests := []struct {
+		name    string
+		opts    []Option
+		webhook string
+		ok      bool
+	}{
+		{"public", []Option{WithBaseURL("https://eden.example.com")}, "https://hooks.example.com/eden", true},
+		{"no base url", nil, "https://hooks.example.com/eden", false},
+		{"relative base url", []Option{WithBaseURL("/eden")}, "https://hooks.example.com/eden", false},
+		{"not http", []Option{WithBaseURL("https://eden.example.com")}, "ftp://example.com", false},
+		{"loopback", []Option{WithBaseURL("https://eden.example.com")}, "http://127.0.0.1:9000/", false},
+		{"localhost", []Option{WithBaseURL("https://eden.example.com")}, "http://localhost/", false},
+		{"private", []Option{WithBaseURL("https://eden.example.com")}, "http://10.0.0.8/", false},
+		{"link local", []Option{WithBaseURL("https://eden.example.com")}, "http://169.254.169.254/latest", false},
+		{"ipv6 loopback", []Option{WithBaseURL("https://eden.example.com")}, "http://[::1]/", false},
+		{"allowed private", []Option{WithBaseURL("https://eden.example.com"), WithWebhookAllowlist("10.0.0.8")}, "http://10.0.0.8/", true},
+		{"not allowed", []Option{WithBaseURL("https://eden.example.com"), WithWebhookAllowlist("10.0.0.8")}, "https://hooks.example.com/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.opts...)
+			defer s.Close()
+			if err := s.validWebhook(tt.webhook); (err == nil) != tt.ok {
+				t.Errorf("validWebhook(%q) = %v, want ok %v", tt.webhook, err, tt.ok)
+			}
+			if !tt.ok {
+				if _, err := s.Submit("a.docx", []byte("x"), cfg, WithWebhook(tt.webhook)); err == nil {
+					t.Errorf("Submit accepted webhook %q", tt.webhook)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_DialWebhook(t *testing.T) {
+	s := New()
+	defer s.Close()
+	if _, err := s.dialWebhook(context.Background(), "tcp", "localhost:80"); err == nil {
+		t.Error("Expected dialing a loopback host to be rejected")
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	sig := Sign([]byte("key"), now, body)
+	if !VerifySignature([]byte("key"), body, now, sig, 0) {
+		t.Error("signature does not verify")
+	}
+	if VerifySignature([]byte("other"), body, now, sig, 0) || VerifySignature([]byte("key"), []byte("{}"), now, sig, 0) {
+		t.Error("signature verifies with the wrong key or body")
+	}
+
+	// 旧的时间戳和篡改的时间戳都不能通过校验
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	if VerifySignature([]byte("key"), body, old, Sign([]byte("key"), old, body), time.Minute) {
+		t.Error("replayed signature verifies")
+	}
+	if VerifySignature([]byte("key"), body, strconv.FormatInt(time.Now().Unix()+1, 10), sig, 0) {
+		t.Error("signature verifies with a different timestamp")
+	}
+}
+
+func TestWebhookDelay(t *testing.T) {
+	cfg := translate.RetryConfig{BaseDelay: time.Second, MaxDelay: 5 * time.Second, BackoffFactor: 2}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		want       time.Duration
+	}{
+		{"backoff", 1, "", 2 * time.Second},
+		{"backoff capped", 5, "", 5 * time.Second},
+		{"retry after seconds", 0, "3", 3 * time.Second},
+		{"retry after date", 0, now.Add(4 * time.Second).Format(http.TimeFormat), 4 * time.Second},
+		{"retry after capped", 0, "60", 5 * time.Second},
+		{"invalid retry after", 0, "soon", time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webhookDelay(cfg, tt.attempt, parseRetryAfter(tt.retryAfter, now)); got != tt.want {
+				t.Errorf("webhookDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
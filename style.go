@@ -0,0 +1,94 @@
+package eden
+
+import (
+	"strings"
+
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/translate"
+)
+
+// WithDomain 设置文档领域，如 translate.DomainLegal，提示词给出对应的翻译要求，
+// OpenAI 翻译器同时按领域调整采样温度
+func WithDomain(domain string) Opt {
+	return func(p *DocxProcessor) {
+		p.domain = domain
+	}
+}
+
+// WithTone 设置译文语气，如 friendly、persuasive
+func WithTone(tone string) Opt {
+	return func(p *DocxProcessor) {
+		p.tone = tone
+	}
+}
+
+// WithFormality 设置正式程度，translate.FormalityFormal 或 translate.FormalityInformal
+func WithFormality(formality string) Opt {
+	return func(p *DocxProcessor) {
+		p.formality = formality
+	}
+}
+
+// WithAudience 设置译文的目标读者，如 developers、patients
+func WithAudience(audience string) Opt {
+	return func(p *DocxProcessor) {
+		p.audience = audience
+	}
+}
+
+// WithLocale 设置目标语言的地区变体，如 zh-TW、en-GB、pt-BR
+// 多个目标语言时按地区代码的语言部分匹配，没有匹配的目标语言不指定地区
+func WithLocale(locales ...string) Opt {
+	return func(p *DocxProcessor) {
+		p.locales = append(p.locales, locales...)
+	}
+}
+
+// NormalizeLocale 规范化地区代码，语言小写、地区大写，下划线替换为连字符，如 zh_tw 转为 zh-TW
+func NormalizeLocale(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		// 两位字母为地区，四位字母为书写系统，如 zh-Hant
+		switch len(parts[i]) {
+		case 2:
+			parts[i] = strings.ToUpper(parts[i])
+		case 4:
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// LocaleLang 地区代码对应的语言，未知语言返回空字符串
+func LocaleLang(locale string) string {
+	code := strings.SplitN(NormalizeLocale(locale), "-", 2)[0]
+	return lang.LangCodes[code]
+}
+
+// localeFor 目标语言使用的地区变体
+func (p *DocxProcessor) localeFor(to string) string {
+	for _, locale := range p.locales {
+		if LocaleLang(locale) == to {
+			return NormalizeLocale(locale)
+		}
+	}
+	return ""
+}
+
+// styleReq 把翻译风格写入请求
+func (p *DocxProcessor) styleReq(tg *target, req *translate.TranReq) {
+	req.Domain = p.domain
+	req.Tone = p.tone
+	req.Formality = p.formality
+	req.Locale = tg.locale
+	req.Audience = p.audience
+}
+
+// styleKey 翻译风格的标识，风格不同的翻译不共用检查点，没有设置风格时为空
+func (p *DocxProcessor) styleKey(tg *target) string {
+	if p.domain == "" && p.tone == "" && p.formality == "" && tg.locale == "" && p.audience == "" {
+		return ""
+	}
+	return strings.Join([]string{p.domain, p.tone, p.formality, tg.locale, p.audience}, "|")
+}
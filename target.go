@@ -0,0 +1,55 @@
+package eden
+
+import (
+	"strings"
+
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/translate"
+)
+
+// target 单个目标语言的翻译状态，多个目标语言共享同一份提取结果
+type target struct {
+	to          string
+	locale      string // 目标语言的地区变体
+	langChecker lang.LanguageChecker
+	tranParaSet map[string]string
+	report      *Report
+	ckpt        *checkpoint
+}
+
+// newTarget 创建目标语言状态，checker 为空时使用目标语言默认的检查器
+func newTarget(from, to string, checker lang.LanguageChecker) *target {
+	if checker == nil {
+		checker = lang.LangMapChecks[to]
+	}
+
+	report := newReport()
+	report.From = from
+	report.To = to
+	return &target{
+		to:          to,
+		langChecker: checker,
+		tranParaSet: make(map[string]string, 0),
+		report:      report,
+	}
+}
+
+// skipChunk 分块已经是目标语言时无需翻译
+func (t *target) skipChunk(para translate.Paragraph) bool {
+	return t.langChecker != nil && t.langChecker.Check(strings.Join(para, "|"))
+}
+
+// isTargetLang 文本已经是目标语言
+func (t *target) isTargetLang(text string) bool {
+	return t.langChecker != nil && t.langChecker.Check(text)
+}
+
+// allTargetLang 文本是所有目标语言时才能在提取时忽略
+func (p *DocxProcessor) allTargetLang(text string) bool {
+	for _, t := range p.targets {
+		if !t.isTargetLang(text) {
+			return false
+		}
+	}
+	return len(p.targets) > 0
+}
@@ -0,0 +1,173 @@
+package tokenizer
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// Tokenizer 分词器接口，用于估算文本占用的 token 数
+type Tokenizer interface {
+	Count(s string) int
+	Name() string
+}
+
+// RuneTokenizer 按字符计数，等同于旧版本按字数分块的行为
+type RuneTokenizer struct{}
+
+func (RuneTokenizer) Count(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+func (RuneTokenizer) Name() string {
+	return "rune"
+}
+
+// EstimateTokenizer 不依赖词表的估算分词器
+// 中日韩文字按每字 1 个 token 计算，其他文字按每 4 字节 1 个 token 计算
+type EstimateTokenizer struct{}
+
+func (EstimateTokenizer) Count(s string) int {
+	cjk := 0
+	other := 0
+	for _, r := range s {
+		if isCJK(r) {
+			cjk++
+			continue
+		}
+		other += utf8.RuneLen(r)
+	}
+	return cjk + (other+3)/4
+}
+
+func (EstimateTokenizer) Name() string {
+	return "estimate"
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// TiktokenTokenizer 使用 OpenAI BPE 词表精确计数
+type TiktokenTokenizer struct {
+	enc  *tiktoken.Tiktoken
+	name string
+}
+
+// NewTiktoken 按模型名创建 tiktoken 分词器，首次使用会下载并缓存词表
+func NewTiktoken(model string) (*TiktokenTokenizer, error) {
+	enc, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		return nil, err
+	}
+	return &TiktokenTokenizer{enc: enc, name: "tiktoken:" + model}, nil
+}
+
+func (t *TiktokenTokenizer) Count(s string) int {
+	return len(t.enc.EncodeOrdinary(s))
+}
+
+func (t *TiktokenTokenizer) Name() string {
+	return t.name
+}
+
+// tkEntry 一个模型的分词器，每个模型只加载一次
+type tkEntry struct {
+	once sync.Once
+	tk   Tokenizer
+}
+
+var (
+	tkMu    sync.Mutex
+	tkCache = map[string]*tkEntry{}
+)
+
+// ForModel 获取模型对应的分词器
+// tiktoken 支持的模型使用精确计数，其他模型或词表加载失败时使用估算分词器
+// 词表在全局锁外下载，只有等待同一模型的协程会阻塞
+func ForModel(model string) Tokenizer {
+	tkMu.Lock()
+	e, ok := tkCache[model]
+	if !ok {
+		e = &tkEntry{}
+		tkCache[model] = e
+	}
+	tkMu.Unlock()
+
+	e.once.Do(func() {
+		e.tk = EstimateTokenizer{}
+		if hasTiktokenEncoding(model) {
+			if t, err := NewTiktoken(model); err == nil {
+				e.tk = t
+			}
+		}
+	})
+	return e.tk
+}
+
+// hasTiktokenEncoding 判断 tiktoken 是否认识该模型，避免为未知模型发起网络请求
+func hasTiktokenEncoding(model string) bool {
+	if model == "" {
+		return false
+	}
+	if _, ok := tiktoken.MODEL_TO_ENCODING[model]; ok {
+		return true
+	}
+	for prefix := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ModelLimit 模型上下文限制
+type ModelLimit struct {
+	Context   int // 上下文窗口 token 数（输入加输出）
+	MaxOutput int // 单次最大输出 token 数
+}
+
+// DefaultModelLimit 未知模型使用的保守限制
+var DefaultModelLimit = ModelLimit{Context: 8192, MaxOutput: 4096}
+
+// ModelLimits 常用模型的上下文限制
+var ModelLimits = map[string]ModelLimit{
+	"gpt-3.5-turbo":         {Context: 16385, MaxOutput: 4096},
+	"gpt-4":                 {Context: 8192, MaxOutput: 4096},
+	"gpt-4o":                {Context: 128000, MaxOutput: 16384},
+	"gpt-4o-mini":           {Context: 128000, MaxOutput: 16384},
+	"glm-4-plus":            {Context: 128000, MaxOutput: 4096},
+	"deepseek-v3":           {Context: 64000, MaxOutput: 8192},
+	"qwen-plus":             {Context: 131072, MaxOutput: 8192},
+	"x-ai/grok-4-fast:free": {Context: 2000000, MaxOutput: 30000},
+}
+
+// LimitFor 获取模型的上下文限制
+func LimitFor(model string) ModelLimit {
+	if l, ok := ModelLimits[model]; ok {
+		return l
+	}
+	return DefaultModelLimit
+}
+
+// DefaultExpansion 译文相对原文的默认 token 膨胀系数
+const DefaultExpansion = 1.5
+
+// ChunkBudget 计算单个分块可用的原文 token 数
+// 需同时满足：提示词 + 原文 + 预计译文 <= 上下文窗口，预计译文 <= 最大输出
+func ChunkBudget(limit ModelLimit, promptTokens int, expansion float64) int {
+	if expansion <= 0 {
+		expansion = DefaultExpansion
+	}
+
+	byContext := int(float64(limit.Context-promptTokens) / (1 + expansion))
+	byOutput := int(float64(limit.MaxOutput) / expansion)
+	budget := min(byContext, byOutput)
+	if budget < 1 {
+		budget = 1
+	}
+	return budget
+}
@@ -0,0 +1,87 @@
+package tokenizer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateTokenizer_Count(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want int
+	}{
+		{"empty", "", 0},
+		{"english", "Hello world!", 3},
+		{"chinese", "你好世界", 4},
+		{"mixed", "你好 world", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (EstimateTokenizer{}).Count(tt.s); got != tt.want {
+				t.Errorf("Count(%q) = %d, want %d", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     ModelLimit
+		prompt    int
+		expansion float64
+		want      int
+	}{
+		{"limited by context", ModelLimit{Context: 1000, MaxOutput: 100000}, 200, 1, 400},
+		{"limited by output", ModelLimit{Context: 128000, MaxOutput: 4096}, 500, 2, 2048},
+		{"default expansion", ModelLimit{Context: 2500, MaxOutput: 100000}, 0, 0, 1000},
+		{"prompt too large", ModelLimit{Context: 100, MaxOutput: 100}, 500, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ChunkBudget(tt.limit, tt.prompt, tt.expansion); got != tt.want {
+				t.Errorf("ChunkBudget() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForModel_Unknown(t *testing.T) {
+	if tk := ForModel("qwen-plus"); tk.Name() != "estimate" {
+		t.Errorf("Expected estimate tokenizer for unknown model, got %s", tk.Name())
+	}
+	if LimitFor("unknown-model") != DefaultModelLimit {
+		t.Error("Expected default limit for unknown model")
+	}
+}
+
+func TestForModel_LoadOutsideLock(t *testing.T) {
+	// 模拟一个正在下载词表的模型
+	slow := &tkEntry{}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	tkMu.Lock()
+	tkCache["slow-model"] = slow
+	tkMu.Unlock()
+	go slow.once.Do(func() {
+		close(started)
+		<-release
+		slow.tk = EstimateTokenizer{}
+	})
+	<-started
+	defer close(release)
+
+	done := make(chan Tokenizer)
+	go func() {
+		done <- ForModel("other-model")
+	}()
+	select {
+	case tk := <-done:
+		if tk.Name() != "estimate" {
+			t.Errorf("Unexpected tokenizer %s", tk.Name())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ForModel blocked by another model's load")
+	}
+}
@@ -0,0 +1,304 @@
+package translate
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gou-jjjj/eden/logger"
+)
+
+// ErrCircuitOpen 熔断器处于打开状态，请求被直接拒绝
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // 关闭：正常放行
+	BreakerOpen                         // 打开：直接拒绝
+	BreakerHalfOpen                     // 半开：放行少量探测请求
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerConfig 熔断器配置
+type BreakerConfig struct {
+	FailureThreshold int           // 连续失败多少次后打开
+	OpenTimeout      time.Duration // 打开后多久进入半开状态
+	HalfOpenProbes   int           // 半开状态下允许的并发探测请求数
+}
+
+// DefaultBreakerConfig 默认熔断器配置
+var DefaultBreakerConfig = BreakerConfig{
+	FailureThreshold: 5,
+	OpenTimeout:      30 * time.Second,
+	HalfOpenProbes:   1,
+}
+
+// StateChange 熔断器的状态变化，由调用方用自己的日志记录器记录
+type StateChange struct {
+	Name     string
+	From     BreakerState
+	To       BreakerState
+	Failures int // 变化时的连续失败次数
+}
+
+// Changed 状态发生了变化
+func (c StateChange) Changed() bool {
+	return c.From != c.To
+}
+
+// Log 把状态变化写入日志，没有变化或 l 为空时不记录
+func (c StateChange) Log(l logger.Logger) {
+	if l == nil || !c.Changed() {
+		return
+	}
+	switch c.To {
+	case BreakerOpen:
+		l.Warn("熔断器[%s]状态: %s -> %s，连续失败 %d 次", c.Name, c.From, c.To, c.Failures)
+	default:
+		l.Info("熔断器[%s]状态: %s -> %s", c.Name, c.From, c.To)
+	}
+}
+
+// Breaker 熔断器，同一个服务商的所有请求共享一个实例
+// 熔断器不持有日志记录器，状态变化返回给调用方，由每个翻译器写入自己的日志
+type Breaker struct {
+	name string
+	cfg  BreakerConfig
+	now  func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+// NewBreaker 创建熔断器
+func NewBreaker(name string, cfg BreakerConfig) *Breaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DefaultBreakerConfig.FailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = DefaultBreakerConfig.OpenTimeout
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = DefaultBreakerConfig.HalfOpenProbes
+	}
+	return &Breaker{
+		name: name,
+		cfg:  cfg,
+		now:  time.Now,
+	}
+}
+
+// Name 熔断器名字
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State 当前状态
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+// Allow 判断是否放行请求，拒绝时返回 ErrCircuitOpen
+// 打开超时后转为半开时返回该状态变化
+func (b *Breaker) Allow() (StateChange, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	change := b.refresh()
+	switch b.state {
+	case BreakerOpen:
+		return change, fmt.Errorf("%w: %s", ErrCircuitOpen, b.name)
+	case BreakerHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return change, fmt.Errorf("%w: %s", ErrCircuitOpen, b.name)
+		}
+		b.probes++
+	}
+	return change, nil
+}
+
+// Success 记录一次成功，返回状态变化
+func (b *Breaker) Success() StateChange {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	return b.setState(BreakerClosed)
+}
+
+// Failure 记录一次失败，返回状态变化
+func (b *Breaker) Failure() StateChange {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+	b.failures++
+	switch b.state {
+	case BreakerHalfOpen:
+		return b.setState(BreakerOpen)
+	case BreakerClosed:
+		if b.failures >= b.cfg.FailureThreshold {
+			return b.setState(BreakerOpen)
+		}
+	}
+	return b.unchanged()
+}
+
+// Release 放弃一次已放行的请求，不记录成功或失败，半开状态下归还探测名额
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// refresh 打开超时后转为半开，调用方需持有锁
+func (b *Breaker) refresh() StateChange {
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		return b.setState(BreakerHalfOpen)
+	}
+	return b.unchanged()
+}
+
+// unchanged 当前状态，没有变化，调用方需持有锁
+func (b *Breaker) unchanged() StateChange {
+	return StateChange{Name: b.name, From: b.state, To: b.state, Failures: b.failures}
+}
+
+// setState 切换状态，状态相同时不做任何事，调用方需持有锁
+func (b *Breaker) setState(state BreakerState) StateChange {
+	change := StateChange{Name: b.name, From: b.state, To: state, Failures: b.failures}
+	if !change.Changed() {
+		return change
+	}
+
+	b.state = state
+	b.probes = 0
+	if state == BreakerOpen {
+		b.openedAt = b.now()
+	}
+	return change
+}
+
+var (
+	breakerMu sync.Mutex
+	breakers  = map[string]*Breaker{}
+)
+
+// ProviderBreaker 获取服务商共享的熔断器，不存在时使用默认配置创建
+func ProviderBreaker(provider string) *Breaker {
+	breakerMu.Lock()
+	defer breakerMu.Unlock()
+
+	b, ok := breakers[provider]
+	if !ok {
+		b = NewBreaker(provider, DefaultBreakerConfig)
+		breakers[provider] = b
+	}
+	return b
+}
+
+// RegisterProviderBreaker 服务商还没有熔断器时用 cfg 创建，已有时返回已有的熔断器
+// 重复注册不会重置熔断器的状态，已经创建的翻译器和之后创建的翻译器共享同一个实例
+func RegisterProviderBreaker(provider string, cfg BreakerConfig) *Breaker {
+	breakerMu.Lock()
+	defer breakerMu.Unlock()
+
+	b, ok := breakers[provider]
+	if !ok {
+		b = NewBreaker(provider, cfg)
+		breakers[provider] = b
+	}
+	return b
+}
+
+// SetProviderBreaker 替换服务商共享的熔断器，可用于自定义配置
+func SetProviderBreaker(provider string, b *Breaker) {
+	breakerMu.Lock()
+	defer breakerMu.Unlock()
+	breakers[provider] = b
+}
+
+// BreakerTran 为任意翻译器附加熔断器
+type BreakerTran struct {
+	tran    Translate
+	breaker *Breaker
+}
+
+// NewBreakerTran 创建带熔断器的翻译器，breaker 为空时使用以翻译器名字共享的熔断器
+func NewBreakerTran(tran Translate, breaker *Breaker) *BreakerTran {
+	if breaker == nil {
+		breaker = ProviderBreaker(tran.Name())
+	}
+	return &BreakerTran{
+		tran:    tran,
+		breaker: breaker,
+	}
+}
+
+func (b *BreakerTran) T(req *TranReq) (Paragraph, error) {
+	return callWithBreaker(b.breaker, nil, func() (Paragraph, error) {
+		return b.tran.T(req)
+	})
+}
+
+func (b *BreakerTran) Name() string {
+	return b.tran.Name()
+}
+
+// Prompt 被包装的翻译器的提示词
+func (b *BreakerTran) Prompt(req *TranReq) (string, error) {
+	return PromptFor(b.tran, req), nil
+}
+
+// callWithBreaker 在熔断器保护下执行 fn，状态变化写入 l，l 可以为空
+// 只有重试可能成功的错误才计为服务商故障，请求本身的错误不影响共享的熔断器
+func callWithBreaker(b *Breaker, l logger.Logger, fn func() (Paragraph, error)) (Paragraph, error) {
+	if b == nil {
+		return fn()
+	}
+	change, err := b.Allow()
+	change.Log(l)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := fn()
+	switch {
+	case err == nil:
+		change = b.Success()
+	case errors.Is(err, ErrSegmentMismatch):
+		// 段落数不匹配是模型输出问题，服务本身可用
+		change = b.Success()
+	case isRetryableError(err):
+		change = b.Failure()
+	default:
+		// 参数错误、鉴权失败、超出上下文长度等只与这次请求有关
+		b.Release()
+		return res, err
+	}
+	change.Log(l)
+	return res, err
+}
@@ -0,0 +1,143 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBreaker_StateTransitions(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewBreaker("test", BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenProbes: 1})
+	b.now = func() time.Time { return now }
+
+	if c := b.Failure(); c.Changed() || b.State() != BreakerClosed {
+		t.Fatalf("Expected closed after 1 failure, got %s (%+v)", b.State(), c)
+	}
+	if c := b.Failure(); c.From != BreakerClosed || c.To != BreakerOpen || c.Failures != 2 {
+		t.Fatalf("Expected closed -> open after 2 failures, got %+v", c)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	// 超时后进入半开，只放行一个探测请求
+	now = now.Add(time.Minute)
+	c, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Expected probe to be allowed, got %v", err)
+	}
+	if c.To != BreakerHalfOpen || b.State() != BreakerHalfOpen {
+		t.Fatalf("Expected half-open, got %s (%+v)", b.State(), c)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected second probe to be rejected, got %v", err)
+	}
+
+	// 探测失败重新打开
+	if c := b.Failure(); c.To != BreakerOpen {
+		t.Fatalf("Expected reopen after failed probe, got %+v", c)
+	}
+
+	// 探测成功关闭
+	now = now.Add(time.Minute)
+	if _, err := b.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	if c := b.Success(); c.To != BreakerClosed || !c.Changed() {
+		t.Fatalf("Expected closed after successful probe, got %+v", c)
+	}
+	if c := b.Success(); c.Changed() {
+		t.Errorf("Expected no change for success while closed, got %+v", c)
+	}
+}
+
+func TestBreaker_LogsToCaller(t *testing.T) {
+	b := NewBreaker("shared", BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour})
+	first, second := &MockLogger{}, &MockLogger{}
+	fail := func() (Paragraph, error) {
+		return nil, errors.New("service unavailable")
+	}
+
+	// 打开熔断器的请求写入自己的日志，共享熔断器的其他翻译器日志不受影响
+	_, _ = callWithBreaker(b, first, fail)
+	_, _ = callWithBreaker(b, second, fail)
+	if len(first.warnLogs) != 1 || len(second.warnLogs) != 0 {
+		t.Errorf("Expected the state change only in the caller's log, got %v and %v", first.warnLogs, second.warnLogs)
+	}
+	_, _ = callWithBreaker(b, nil, fail)
+}
+
+func TestBreakerTran_ShortCircuitToFallback(t *testing.T) {
+	down := &stubTran{name: "down", err: errors.New("service unavailable")}
+	backup := &stubTran{name: "backup", res: Paragraph{"ok"}}
+	b := NewBreaker("down", BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour})
+
+	c := Fallback(NewBreakerTran(down, b), backup)
+	for i := 0; i < 5; i++ {
+		if _, err := c.T(&TranReq{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if down.calls != 1 {
+		t.Errorf("Expected open breaker to skip provider, got %d calls", down.calls)
+	}
+	if backup.calls != 5 {
+		t.Errorf("Expected fallback for every request, got %d calls", backup.calls)
+	}
+}
+
+func TestBreaker_IgnoreSegmentMismatch(t *testing.T) {
+	b := NewBreaker("mismatch", BreakerConfig{FailureThreshold: 1})
+	_, err := callWithBreaker(b, nil, func() (Paragraph, error) {
+		return nil, ErrSegmentMismatch
+	})
+	if !errors.Is(err, ErrSegmentMismatch) {
+		t.Fatalf("Expected ErrSegmentMismatch, got %v", err)
+	}
+	if b.State() != BreakerClosed {
+		t.Errorf("Expected mismatch not to open breaker, got %s", b.State())
+	}
+}
+
+func TestBreaker_IgnoreRequestErrors(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewBreaker("request", BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenProbes: 1})
+	b.now = func() time.Time { return now }
+
+	for _, err := range []error{
+		&APIError{StatusCode: http.StatusBadRequest, Code: "context_length_exceeded"},
+		&APIError{StatusCode: http.StatusUnauthorized},
+		context.Canceled,
+	} {
+		_, got := callWithBreaker(b, nil, func() (Paragraph, error) {
+			return nil, err
+		})
+		if !errors.Is(got, err) {
+			t.Fatalf("Expected %v to pass through, got %v", err, got)
+		}
+		if b.State() != BreakerClosed {
+			t.Fatalf("Expected %v not to open breaker, got %s", err, b.State())
+		}
+	}
+
+	// 服务端错误打开熔断器
+	_, _ = callWithBreaker(b, nil, func() (Paragraph, error) {
+		return nil, &APIError{StatusCode: http.StatusServiceUnavailable}
+	})
+	if b.State() != BreakerOpen {
+		t.Fatalf("Expected 503 to open breaker, got %s", b.State())
+	}
+
+	// 半开状态下请求错误归还探测名额
+	now = now.Add(time.Minute)
+	_, _ = callWithBreaker(b, nil, func() (Paragraph, error) {
+		return nil, &APIError{StatusCode: http.StatusBadRequest}
+	})
+	if _, err := b.Allow(); err != nil || b.State() != BreakerHalfOpen {
+		t.Errorf("Expected probe to be released, got %v in %s", err, b.State())
+	}
+}
@@ -0,0 +1,169 @@
+package translate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/logger"
+)
+
+// ErrNoTranslator 没有可用的翻译器
+var ErrNoTranslator = errors.New("no translator available")
+
+// isNilTranslate 判断翻译器是否为空（包括带类型的空指针）
+func isNilTranslate(t Translate) bool {
+	if t == nil {
+		return true
+	}
+	v := reflect.ValueOf(t)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// Chain 翻译器链，按顺序尝试每个成员，直到有一个成功
+type Chain struct {
+	members []Translate
+	logger  logger.Logger
+}
+
+// NewChain 创建翻译器链，空成员会被忽略
+func NewChain(members ...Translate) *Chain {
+	c := &Chain{}
+	for _, m := range members {
+		if !isNilTranslate(m) {
+			c.members = append(c.members, m)
+		}
+	}
+	return c
+}
+
+// NewChainWithLogger 创建带日志记录器的翻译器链
+func NewChainWithLogger(logger logger.Logger, members ...Translate) *Chain {
+	c := NewChain(members...)
+	c.logger = logger
+	return c
+}
+
+// Fallback 创建以 primary 为主、backups 依次兜底的翻译器链
+func Fallback(primary Translate, backups ...Translate) *Chain {
+	return NewChain(append([]Translate{primary}, backups...)...)
+}
+
+func (c *Chain) T(req *TranReq) (Paragraph, error) {
+	if len(c.members) == 0 {
+		return nil, ErrNoTranslator
+	}
+
+	errs := make([]error, 0, len(c.members))
+	for i, m := range c.members {
+		res, err := m.T(req)
+		if err == nil {
+			return res, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", m.Name(), err))
+		if c.logger != nil && i < len(c.members)-1 {
+			c.logger.Warn("翻译器 %s 失败，切换到 %s，错误: %v", m.Name(), c.members[i+1].Name(), err)
+		}
+	}
+
+	if c.logger != nil {
+		c.logger.Error("翻译器链全部失败: %v", errors.Join(errs...))
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (c *Chain) Name() string {
+	names := make([]string, 0, len(c.members))
+	for _, m := range c.members {
+		names = append(names, m.Name())
+	}
+	return fmt.Sprintf("Chain(%s)", strings.Join(names, ","))
+}
+
+// Prompt 成员中最长的提示词，任意成员都可能处理该请求
+func (c *Chain) Prompt(req *TranReq) (string, error) {
+	res := ""
+	for _, m := range c.members {
+		if s := PromptFor(m, req); len(s) > len(res) {
+			res = s
+		}
+	}
+	return res, nil
+}
+
+// route 路由规则
+type route struct {
+	match func(*TranReq) bool
+	tran  Translate
+}
+
+// Router 翻译路由器，按语言对或文档领域选择翻译器
+type Router struct {
+	routes []route
+	def    Translate
+}
+
+// NewRouter 创建路由器，def 为没有规则命中时使用的翻译器，可以为空
+func NewRouter(def Translate) *Router {
+	r := &Router{}
+	if !isNilTranslate(def) {
+		r.def = def
+	}
+	return r
+}
+
+// Match 添加自定义路由规则，规则按添加顺序匹配
+func (r *Router) Match(match func(*TranReq) bool, tran Translate) *Router {
+	if match != nil && !isNilTranslate(tran) {
+		r.routes = append(r.routes, route{match: match, tran: tran})
+	}
+	return r
+}
+
+// LangPair 按语言对路由，from 为 lang.All 时匹配任意源语言
+func (r *Router) LangPair(from, to string, tran Translate) *Router {
+	return r.Match(func(req *TranReq) bool {
+		return (from == lang.All || req.From == from) && req.To == to
+	}, tran)
+}
+
+// Domain 按文档领域路由
+func (r *Router) Domain(domain string, tran Translate) *Router {
+	return r.Match(func(req *TranReq) bool {
+		return req.Domain == domain
+	}, tran)
+}
+
+// Pick 选择处理该请求的翻译器
+func (r *Router) Pick(req *TranReq) Translate {
+	for _, rt := range r.routes {
+		if rt.match(req) {
+			return rt.tran
+		}
+	}
+	return r.def
+}
+
+func (r *Router) T(req *TranReq) (Paragraph, error) {
+	tran := r.Pick(req)
+	if tran == nil {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrNoTranslator, req.From, req.To)
+	}
+	return tran.T(req)
+}
+
+// Prompt 处理该请求的翻译器的提示词
+func (r *Router) Prompt(req *TranReq) (string, error) {
+	tran := r.Pick(req)
+	if tran == nil {
+		return "", fmt.Errorf("%w: %s -> %s", ErrNoTranslator, req.From, req.To)
+	}
+	return PromptFor(tran, req), nil
+}
+
+func (r *Router) Name() string {
+	return "Router"
+}
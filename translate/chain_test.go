@@ -0,0 +1,119 @@
+package translate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gou-jjjj/eden/lang"
+)
+
+// stubTran 固定返回结果或错误的翻译器
+type stubTran struct {
+	name  string
+	res   Paragraph
+	err   error
+	calls int
+}
+
+func (s *stubTran) T(req *TranReq) (Paragraph, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.res, nil
+}
+
+func (s *stubTran) Name() string {
+	return s.name
+}
+
+func TestChain_Fallback(t *testing.T) {
+	primary := &stubTran{name: "primary", err: errors.New("authentication failed")}
+	backup := &stubTran{name: "backup", res: Paragraph{"ok"}}
+
+	var nilOpenai *TranOpenai
+	c := Fallback(primary, nilOpenai, backup)
+
+	got, err := c.T(&TranReq{From: lang.EN, To: lang.ZH, Paras: Paragraph{"hi"}})
+	if err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "ok" {
+		t.Errorf("Expected backup result, got %v", got)
+	}
+	if primary.calls != 1 || backup.calls != 1 {
+		t.Errorf("Expected one call each, got primary=%d backup=%d", primary.calls, backup.calls)
+	}
+	if c.Name() != "Chain(primary,backup)" {
+		t.Errorf("Unexpected name %s", c.Name())
+	}
+}
+
+func TestChain_AllFailed(t *testing.T) {
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	c := NewChain(&stubTran{name: "a", err: errA}, &stubTran{name: "b", err: errB})
+
+	_, err := c.T(&TranReq{})
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Expected joined errors, got %v", err)
+	}
+
+	if _, err := NewChain().T(&TranReq{}); !errors.Is(err, ErrNoTranslator) {
+		t.Errorf("Expected ErrNoTranslator, got %v", err)
+	}
+}
+
+func TestChain_MemberRetry(t *testing.T) {
+	flaky := &stubTran{name: "flaky", err: errors.New("service unavailable")}
+	backup := &stubTran{name: "backup", res: Paragraph{"backup"}}
+	cfg := RetryConfig{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, BackoffFactor: 1}
+
+	c := NewChain(NewRetry(flaky, cfg), backup)
+	got, err := c.T(&TranReq{})
+	if err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	if flaky.calls != 3 || backup.calls != 1 {
+		t.Errorf("Expected member retries before fallback, flaky=%d backup=%d", flaky.calls, backup.calls)
+	}
+	if got[0] != "backup" {
+		t.Errorf("Unexpected result %v", got)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	def := &stubTran{name: "def", res: Paragraph{"def"}}
+	zh := &stubTran{name: "zh", res: Paragraph{"zh"}}
+	legal := &stubTran{name: "legal", res: Paragraph{"legal"}}
+
+	r := NewRouter(def).
+		Domain("legal", legal).
+		LangPair(lang.All, lang.ZH, zh)
+
+	tests := []struct {
+		name string
+		req  *TranReq
+		want string
+	}{
+		{"domain first", &TranReq{From: lang.EN, To: lang.ZH, Domain: "legal"}, "legal"},
+		{"lang pair", &TranReq{From: lang.EN, To: lang.ZH}, "zh"},
+		{"default", &TranReq{From: lang.ZH, To: lang.EN}, "def"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.T(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got[0] != tt.want {
+				t.Errorf("Router picked %s, want %s", got[0], tt.want)
+			}
+		})
+	}
+
+	if _, err := NewRouter(nil).T(&TranReq{From: lang.EN, To: lang.JA}); !errors.Is(err, ErrNoTranslator) {
+		t.Errorf("Expected ErrNoTranslator, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package translate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gou-jjjj/eden/prompt"
+)
+
+// DocContext 文档级上下文，帮助翻译器在分块之间保持术语、指代和语气一致
+type DocContext struct {
+	Summary  string `json:"summary,omitempty"`  // 文档摘要：主题、读者和关键术语
+	Previous []Pair `json:"previous,omitempty"` // 分块之前的原文和译文，按文档顺序
+}
+
+// Pair 原文和译文，译文尚未完成时为空
+type Pair = prompt.Pair
+
+// Empty 上下文中没有任何内容
+func (c *DocContext) Empty() bool {
+	return c == nil || (c.Summary == "" && len(c.Previous) == 0)
+}
+
+// SummaryReq 文档摘要请求
+type SummaryReq struct {
+	Text  string       // 文档全文或开头部分
+	Lang  string       // 摘要使用的语言
+	Usage UsageByModel // 摘要器上报的用量
+}
+
+// AddUsage 摘要器上报一次调用的用量
+func (r *SummaryReq) AddUsage(model string, u Usage) {
+	if r.Usage == nil {
+		r.Usage = UsageByModel{}
+	}
+	r.Usage.Add(model, u)
+}
+
+// Summarizer 文档摘要器，在翻译前概括文档的主题、读者和关键术语
+type Summarizer interface {
+	Summarize(req *SummaryReq) (string, error)
+}
+
+// ErrNoSummarizer 翻译器不支持生成摘要
+var ErrNoSummarizer = errors.New("translator does not support summaries")
+
+// Summarize 依次尝试支持摘要的成员
+func (c *Chain) Summarize(req *SummaryReq) (string, error) {
+	errs := make([]error, 0)
+	for _, m := range c.members {
+		s, ok := m.(Summarizer)
+		if !ok {
+			continue
+		}
+		summary, err := s.Summarize(req)
+		if err == nil {
+			return summary, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", m.Name(), err))
+	}
+	if len(errs) == 0 {
+		return "", ErrNoSummarizer
+	}
+	return "", errors.Join(errs...)
+}
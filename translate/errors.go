@@ -0,0 +1,187 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIError 翻译服务返回的 HTTP 错误
+type APIError struct {
+	Provider   string        // 服务商，见 OpenaiModelList
+	Model      string        // 请求使用的模型
+	StatusCode int           // HTTP 状态码
+	Code       string        // 服务商错误码
+	Message    string        // 服务商错误信息
+	RetryAfter time.Duration // 服务端建议的重试等待时间，0 表示没有
+	Err        error         // 原始错误
+}
+
+func (e *APIError) Error() string {
+	s := e.Provider
+	if e.Model != "" {
+		s += fmt.Sprintf(" (%s)", e.Model)
+	}
+	s += fmt.Sprintf(": status %d", e.StatusCode)
+	if e.Code != "" {
+		s += fmt.Sprintf(", code %s", e.Code)
+	}
+	if e.Message != "" {
+		s += ": " + e.Message
+	} else if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// nonRetryableCodes 即使状态码可重试也不应重试的服务商错误码
+var nonRetryableCodes = map[string]bool{
+	"insufficient_quota":      true,
+	"billing_hard_limit":      true,
+	"invalid_api_key":         true,
+	"model_not_found":         true,
+	"context_length_exceeded": true,
+}
+
+// Retryable 根据状态码和错误码判断是否可重试
+func (e *APIError) Retryable() bool {
+	if nonRetryableCodes[e.Code] {
+		return false
+	}
+
+	switch e.StatusCode {
+	case http.StatusRequestTimeout,
+		http.StatusConflict,
+		http.StatusTooEarly,
+		http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+		return false
+	}
+	return e.StatusCode >= 500
+}
+
+// parseRetryAfter 解析 Retry-After 头，支持秒数和 HTTP 日期两种格式
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// respMeta 记录一次请求的响应元信息，由 metaTransport 填充
+type respMeta struct {
+	mu         sync.Mutex
+	statusCode int
+	code       string
+	message    string
+	retryAfter time.Duration
+}
+
+type respMetaKey struct{}
+
+// withRespMeta 在上下文中放入响应元信息记录器
+func withRespMeta(ctx context.Context) (context.Context, *respMeta) {
+	m := &respMeta{}
+	return context.WithValue(ctx, respMetaKey{}, m), m
+}
+
+// apiError 把请求错误和响应元信息合成为 APIError，没有收到 HTTP 错误响应时原样返回
+func (m *respMeta) apiError(provider, model string, err error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.statusCode == 0 {
+		return err
+	}
+	return &APIError{
+		Provider:   provider,
+		Model:      model,
+		StatusCode: m.statusCode,
+		Code:       m.code,
+		Message:    m.message,
+		RetryAfter: m.retryAfter,
+		Err:        err,
+	}
+}
+
+// metaTransport 记录错误响应的状态码、错误码和 Retry-After
+type metaTransport struct {
+	base http.RoundTripper
+}
+
+func (t *metaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 400 {
+		return resp, err
+	}
+
+	m, ok := req.Context().Value(respMetaKey{}).(*respMeta)
+	if !ok {
+		return resp, nil
+	}
+
+	// 读取错误响应体后放回，保证上游仍能解析
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	code, message := parseErrorBody(body)
+
+	m.mu.Lock()
+	m.statusCode = resp.StatusCode
+	m.code = code
+	m.message = message
+	m.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	m.mu.Unlock()
+
+	return resp, nil
+}
+
+// parseErrorBody 解析 OpenAI 兼容接口的错误响应体
+func parseErrorBody(body []byte) (code, message string) {
+	var errResp struct {
+		Error struct {
+			Code    json.RawMessage `json:"code"`
+			Type    string          `json:"type"`
+			Message string          `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &errResp); err != nil {
+		return "", ""
+	}
+
+	code = strings.Trim(string(errResp.Error.Code), `"`)
+	if code == "" || code == "null" {
+		code = errResp.Error.Type
+	}
+	return code, errResp.Error.Message
+}
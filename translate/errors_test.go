@@ -0,0 +1,120 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gou-jjjj/eden/lang"
+)
+
+func TestAPIError_Retryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"429", &APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"503", &APIError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"501", &APIError{StatusCode: http.StatusNotImplemented}, false},
+		{"401", &APIError{StatusCode: http.StatusUnauthorized}, false},
+		{"400", &APIError{StatusCode: http.StatusBadRequest}, false},
+		{"429 quota", &APIError{StatusCode: http.StatusTooManyRequests, Code: "insufficient_quota"}, false},
+		{"wrapped 502", fmt.Errorf("call: %w", &APIError{StatusCode: http.StatusBadGateway}), true},
+		{"segment mismatch", fmt.Errorf("%w，req:1!=res:2", ErrSegmentMismatch), true},
+		{"circuit open", ErrCircuitOpen, false},
+		{"deadline", context.DeadlineExceeded, true},
+		{"canceled", context.Canceled, false},
+		{"untyped retry word", errors.New("please retry later"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.expected {
+				t.Errorf("isRetryableError() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"0.5", 500 * time.Millisecond},
+		{"-1", 0},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{"garbage", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay_RetryAfter(t *testing.T) {
+	cfg := RetryConfig{MaxRetries: 3, BaseDelay: time.Second, MaxDelay: 5 * time.Second, BackoffFactor: 2}
+
+	if d := cfg.retryDelay(0, &APIError{StatusCode: 429, RetryAfter: 3 * time.Second}); d != 3*time.Second {
+		t.Errorf("Expected Retry-After to be honored, got %v", d)
+	}
+
+	if d := cfg.retryDelay(0, &APIError{StatusCode: 429, RetryAfter: time.Minute}); d != cfg.MaxDelay {
+		t.Errorf("Expected Retry-After to be clamped to MaxDelay, got %v", d)
+	}
+
+	if d := cfg.retryDelay(1, errors.New("timeout")); d != 2*time.Second {
+		t.Errorf("Expected backoff delay 2s, got %v", d)
+	}
+}
+
+func TestRetryConfig_Jitter(t *testing.T) {
+	cfg := RetryConfig{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, BackoffFactor: 2, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		d := cfg.delay(1)
+		if d < 100*time.Millisecond || d > 300*time.Millisecond {
+			t.Fatalf("Jittered delay %v out of range", d)
+		}
+	}
+}
+
+func TestPerformTranslation_APIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"code":"rate_limit_exceeded","message":"slow down"}}`))
+	}))
+	defer srv.Close()
+
+	tr := &TranOpenai{source: ZhiPu, url: srv.URL, key: "test", model: "test-model"}
+	_, err := tr.performTranslation(&TranReq{From: lang.EN, To: lang.ZH, Paras: Paragraph{"Hello"}})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %T %v", err, err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Code != "rate_limit_exceeded" ||
+		apiErr.RetryAfter != 2*time.Second || apiErr.Message != "slow down" {
+		t.Errorf("Unexpected APIError %+v", apiErr)
+	}
+	if apiErr.Provider != ZhiPu || apiErr.Model != "test-model" {
+		t.Errorf("Expected provider %s and model test-model, got %q and %q", ZhiPu, apiErr.Provider, apiErr.Model)
+	}
+	if want := ZhiPu + " (test-model): status 429"; !strings.HasPrefix(apiErr.Error(), want) {
+		t.Errorf("Error() = %q, want prefix %q", apiErr.Error(), want)
+	}
+	if !isRetryableError(err) {
+		t.Error("Expected 429 to be retryable")
+	}
+}
@@ -0,0 +1,110 @@
+package translate
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimiter 令牌桶限流器，多个处理器共享同一个限流器时限制总请求速率
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒生成的令牌数
+	burst  float64 // 桶容量
+	tokens float64
+	last   time.Time
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewRateLimiter 创建限流器，rate 为每秒请求数，burst 为允许的突发请求数
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+var (
+	limiterMu sync.Mutex
+	limiters  = map[string]*RateLimiter{}
+)
+
+// SharedRateLimiter 按服务商和速率共享的限流器，相同参数第一次调用时创建，之后返回同一个实例
+// 同一进程中的多个任务使用相同配置时限制的是总请求速率
+func SharedRateLimiter(provider string, rate float64, burst int) *RateLimiter {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+
+	key := fmt.Sprintf("%s|%g|%d", provider, rate, burst)
+	l, ok := limiters[key]
+	if !ok {
+		l = NewRateLimiter(rate, burst)
+		limiters[key] = l
+	}
+	return l
+}
+
+// reserve 预定一个令牌，返回需要等待的时间
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	// 令牌不足时先预支，等待时间由欠下的令牌数决定
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Wait 阻塞直到获得一个令牌，nil 或速率不大于 0 时不限流
+func (l *RateLimiter) Wait() {
+	if l == nil || l.rate <= 0 {
+		return
+	}
+	if d := l.reserve(); d > 0 {
+		l.sleep(d)
+	}
+}
+
+// LimitTran 限流翻译器，每次调用前等待令牌
+type LimitTran struct {
+	tran    Translate
+	limiter *RateLimiter
+}
+
+// NewLimitTran 为翻译器添加限流
+func NewLimitTran(tran Translate, limiter *RateLimiter) *LimitTran {
+	return &LimitTran{tran: tran, limiter: limiter}
+}
+
+func (t *LimitTran) T(req *TranReq) (Paragraph, error) {
+	t.limiter.Wait()
+	return t.tran.T(req)
+}
+
+func (t *LimitTran) Name() string {
+	return t.tran.Name()
+}
+
+// Prompt 被包装的翻译器的提示词
+func (t *LimitTran) Prompt(req *TranReq) (string, error) {
+	return PromptFor(t.tran, req), nil
+}
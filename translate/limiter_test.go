@@ -0,0 +1,38 @@
+package translate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	now := time.Unix(0, 0)
+	var slept []time.Duration
+	l := NewRateLimiter(2, 2)
+	l.now = func() time.Time { return now }
+	l.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		now = now.Add(d)
+	}
+
+	// 突发请求不等待，之后按速率等待
+	for i := 0; i < 4; i++ {
+		l.Wait()
+	}
+	if len(slept) != 2 || slept[0] != 500*time.Millisecond || slept[1] != 500*time.Millisecond {
+		t.Errorf("Unexpected waits %v", slept)
+	}
+
+	// 空闲后令牌恢复，但不超过桶容量
+	now = now.Add(time.Hour)
+	slept = nil
+	for i := 0; i < 3; i++ {
+		l.Wait()
+	}
+	if len(slept) != 1 {
+		t.Errorf("Expected 1 wait after refill, got %v", slept)
+	}
+
+	var nilLimiter *RateLimiter
+	nilLimiter.Wait()
+}
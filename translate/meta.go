@@ -0,0 +1,72 @@
+package translate
+
+import (
+	"strings"
+)
+
+// Segment 片段的来源信息，与 TranReq.Paras 按下标一一对应
+type Segment struct {
+	ID        string `json:"id"`                   // 片段标识，同一文档多次提取时保持不变，如 p12.s0
+	Part      string `json:"part,omitempty"`       // 所在文档部件，如 document
+	Paragraph int    `json:"paragraph"`            // 段落在部件中的序号
+	Style     string `json:"style,omitempty"`      // 段落样式名，如 Heading 1
+	TableCell bool   `json:"table_cell,omitempty"` // 位于表格单元格中
+	Prev      string `json:"prev,omitempty"`       // 文档中前一个片段的原文
+	Next      string `json:"next,omitempty"`       // 文档中后一个片段的原文
+
+	Lang       string  `json:"lang,omitempty"`       // 检测到的原文语言，无法判断时为空
+	Confidence float64 `json:"confidence,omitempty"` // 语言检测的置信度
+}
+
+// Heading 段落样式是标题
+func (s Segment) Heading() bool {
+	name := strings.ToLower(s.Style)
+	return strings.HasPrefix(name, "heading") || name == "title" || name == "subtitle"
+}
+
+// Note 片段的格式说明，供提示词提醒模型，普通正文返回空字符串
+func (s Segment) Note() string {
+	notes := make([]string, 0, 2)
+	if s.Style != "" && !strings.EqualFold(s.Style, "Normal") {
+		notes = append(notes, s.Style)
+	}
+	if s.TableCell {
+		notes = append(notes, "table cell")
+	}
+	return strings.Join(notes, ", ")
+}
+
+// JobMeta 请求所属的任务和文档
+type JobMeta struct {
+	JobID    string            `json:"job_id,omitempty"`   // 任务 ID
+	Document string            `json:"document,omitempty"` // 文档名
+	Chunk    int               `json:"chunk"`              // 分块序号
+	Chunks   int               `json:"chunks"`             // 分块总数
+	Labels   map[string]string `json:"labels,omitempty"`   // 调用方自定义的标签
+}
+
+// ReqOptions 单次请求的选项，零值表示使用翻译器的配置
+type ReqOptions struct {
+	Model       string   `json:"model,omitempty"`       // 替换翻译器的模型
+	Temperature *float64 `json:"temperature,omitempty"` // 采样温度，优先于领域默认温度
+	MaxTokens   int      `json:"max_tokens,omitempty"`  // 最多生成的 token 数
+}
+
+// Segment 第 i 个片段的来源信息，没有时返回零值
+func (r *TranReq) Segment(i int) Segment {
+	if i >= 0 && i < len(r.Segments) {
+		return r.Segments[i]
+	}
+	return Segment{}
+}
+
+// Notes 需要提醒模型的片段格式说明，键为片段从 1 开始的序号
+func (r *TranReq) Notes() map[int]string {
+	notes := map[int]string{}
+	for i := range r.Paras {
+		if note := r.Segment(i).Note(); note != "" {
+			notes[i+1] = note
+		}
+	}
+	return notes
+}
@@ -8,9 +8,16 @@ func NewMockTran() *MockTran {
 }
 
 func (t MockTran) T(r *TranReq) (Paragraph, error) {
+	r.AddUsage(t.Name(), Usage{Requests: 1})
 	return r.Paras, nil
 }
 
 func (t MockTran) Name() string {
 	return "Mock"
 }
+
+// Summarize 返回固定的摘要，用于测试
+func (t MockTran) Summarize(r *SummaryReq) (string, error) {
+	r.AddUsage(t.Name(), Usage{Requests: 1})
+	return "Mock summary.", nil
+}
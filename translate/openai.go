@@ -2,14 +2,16 @@ package translate
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"math"
-	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/gou-jjjj/eden/lang"
 	"github.com/gou-jjjj/eden/logger"
 	"github.com/gou-jjjj/eden/prompt"
 	"github.com/tmc/langchaingo/llms"
@@ -25,21 +27,8 @@ const (
 	Seq = "\n---\n"
 )
 
-// RetryConfig 重试配置
-type RetryConfig struct {
-	MaxRetries    int           // 最大重试次数
-	BaseDelay     time.Duration // 基础延迟时间
-	MaxDelay      time.Duration // 最大延迟时间
-	BackoffFactor float64       // 退避因子
-}
-
-// DefaultRetryConfig 默认重试配置
-var DefaultRetryConfig = RetryConfig{
-	MaxRetries:    3,
-	BaseDelay:     time.Second,
-	MaxDelay:      30 * time.Second,
-	BackoffFactor: 2.0,
-}
+// ErrSegmentMismatch 翻译结果段落数与请求不一致
+var ErrSegmentMismatch = errors.New("response error")
 
 var OpenaiModelList = map[string]struct {
 	Url   string
@@ -53,207 +42,212 @@ var OpenaiModelList = map[string]struct {
 }
 
 type TranOpenai struct {
+	source      string // 服务商名，见 OpenaiModelList
 	url         string
 	key         string
 	model       string
-	back        *TranOpenai
+	back        Translate // 备用翻译器，更复杂的组合请使用 Chain
 	retryConfig RetryConfig
 	logger      logger.Logger
+	breaker     *Breaker // 同一服务商共享的熔断器
+	template    *prompt.Template
+	examples    *prompt.Examples
+
+	// 客户端配置，首次请求时构建，之后所有协程共享，构建失败时下次请求重新构建
+	httpClient *http.Client
+	proxy      string
+	tlsConfig  *tls.Config
+	timeout    time.Duration
+	llmMu      sync.Mutex
+	llm        *openai.LLM
+}
+
+// OpenaiOpt OpenAI翻译器选项
+type OpenaiOpt func(*TranOpenai)
+
+// WithRetryConfig 设置重试配置
+func WithRetryConfig(retryConfig RetryConfig) OpenaiOpt {
+	return func(t *TranOpenai) {
+		t.retryConfig = retryConfig
+	}
+}
+
+// WithOpenaiLogger 设置日志记录器
+func WithOpenaiLogger(logger logger.Logger) OpenaiOpt {
+	return func(t *TranOpenai) {
+		t.logger = logger
+	}
+}
+
+// WithBack 设置备用翻译器
+func WithBack(back Translate) OpenaiOpt {
+	return func(t *TranOpenai) {
+		t.back = firstBack([]Translate{back})
+	}
+}
+
+// WithHTTPClient 使用自定义的 http.Client，设置后忽略代理、TLS 和超时选项
+func WithHTTPClient(client *http.Client) OpenaiOpt {
+	return func(t *TranOpenai) {
+		t.httpClient = client
+	}
+}
+
+// WithProxy 设置代理地址，如 http://127.0.0.1:7890
+func WithProxy(proxy string) OpenaiOpt {
+	return func(t *TranOpenai) {
+		t.proxy = proxy
+	}
+}
+
+// WithTLSConfig 设置 TLS 配置
+func WithTLSConfig(cfg *tls.Config) OpenaiOpt {
+	return func(t *TranOpenai) {
+		t.tlsConfig = cfg
+	}
+}
+
+// WithTimeout 设置单次请求超时时间
+func WithTimeout(timeout time.Duration) OpenaiOpt {
+	return func(t *TranOpenai) {
+		t.timeout = timeout
+	}
+}
+
+// WithPromptTemplate 使用自定义的提示词模板，默认使用 prompt.Default
+func WithPromptTemplate(tmpl *prompt.Template) OpenaiOpt {
+	return func(t *TranOpenai) {
+		t.template = tmpl
+	}
+}
+
+// WithExamples 使用自定义的少样本示例集，默认使用 prompt.DefaultExamples
+func WithExamples(examples *prompt.Examples) OpenaiOpt {
+	return func(t *TranOpenai) {
+		t.examples = examples
+	}
+}
+
+// NewOpenaiWithOpts 使用选项创建OpenAI翻译器
+func NewOpenaiWithOpts(llmSource string, opts ...OpenaiOpt) *TranOpenai {
+	t := NewOpenai(llmSource)
+	if t == nil {
+		return nil
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(t)
+		}
+	}
+	return t
 }
 
-func NewOpenai(llmSource string, backTranOpenai ...*TranOpenai) *TranOpenai {
+func NewOpenai(llmSource string, backTranOpenai ...Translate) *TranOpenai {
 	s, ok := OpenaiModelList[llmSource]
 	if !ok {
 		return nil
 	}
 
 	return &TranOpenai{
+		source:      llmSource,
 		url:         s.Url,
 		key:         s.Key,
 		model:       s.Model,
 		retryConfig: DefaultRetryConfig,
-		back: func() *TranOpenai {
-			if len(backTranOpenai) > 0 {
-				return backTranOpenai[0]
-			}
-			return nil
-		}(),
+		back:        firstBack(backTranOpenai),
+		breaker:     ProviderBreaker(llmSource),
 	}
 }
 
 // NewOpenaiWithRetry 创建带自定义重试配置的OpenAI翻译器
-func NewOpenaiWithRetry(llmSource string, retryConfig RetryConfig, backTranOpenai ...*TranOpenai) *TranOpenai {
+func NewOpenaiWithRetry(llmSource string, retryConfig RetryConfig, backTranOpenai ...Translate) *TranOpenai {
 	s, ok := OpenaiModelList[llmSource]
 	if !ok {
 		return nil
 	}
 
 	return &TranOpenai{
+		source:      llmSource,
 		url:         s.Url,
 		key:         s.Key,
 		model:       s.Model,
 		retryConfig: retryConfig,
-		back: func() *TranOpenai {
-			if len(backTranOpenai) > 0 {
-				return backTranOpenai[0]
-			}
-			return nil
-		}(),
+		back:        firstBack(backTranOpenai),
+		breaker:     ProviderBreaker(llmSource),
 	}
 }
 
 // NewOpenaiWithLogger 创建带日志记录器的OpenAI翻译器
-func NewOpenaiWithLogger(llmSource string, logger logger.Logger, backTranOpenai ...*TranOpenai) *TranOpenai {
+func NewOpenaiWithLogger(llmSource string, logger logger.Logger, backTranOpenai ...Translate) *TranOpenai {
 	s, ok := OpenaiModelList[llmSource]
 	if !ok {
 		return nil
 	}
 
 	return &TranOpenai{
+		source:      llmSource,
 		url:         s.Url,
 		key:         s.Key,
 		model:       s.Model,
 		retryConfig: DefaultRetryConfig,
 		logger:      logger,
-		back: func() *TranOpenai {
-			if len(backTranOpenai) > 0 {
-				return backTranOpenai[0]
-			}
-			return nil
-		}(),
+		back:        firstBack(backTranOpenai),
+		breaker:     ProviderBreaker(llmSource),
 	}
 }
 
 // NewOpenaiWithRetryAndLogger 创建带自定义重试配置和日志记录器的OpenAI翻译器
-func NewOpenaiWithRetryAndLogger(llmSource string, retryConfig RetryConfig, logger logger.Logger, backTranOpenai ...*TranOpenai) *TranOpenai {
+func NewOpenaiWithRetryAndLogger(llmSource string, retryConfig RetryConfig, logger logger.Logger, backTranOpenai ...Translate) *TranOpenai {
 	s, ok := OpenaiModelList[llmSource]
 	if !ok {
 		return nil
 	}
 
 	return &TranOpenai{
+		source:      llmSource,
 		url:         s.Url,
 		key:         s.Key,
 		model:       s.Model,
 		retryConfig: retryConfig,
 		logger:      logger,
-		back: func() *TranOpenai {
-			if len(backTranOpenai) > 0 {
-				return backTranOpenai[0]
-			}
-			return nil
-		}(),
+		back:        firstBack(backTranOpenai),
+		breaker:     ProviderBreaker(llmSource),
 	}
 }
 
-// isRetryableError 判断错误是否可重试
-func isRetryableError(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	// 网络相关错误
-	if netErr, ok := err.(net.Error); ok {
-		return netErr.Temporary() || netErr.Timeout()
-	}
-
-	// 检查错误字符串中的常见可重试错误
-	errStr := strings.ToLower(err.Error())
-	retryablePatterns := []string{
-		"timeout",
-		"connection refused",
-		"connection reset",
-		"network is unreachable",
-		"temporary failure",
-		"rate limit",
-		"too many requests",
-		"service unavailable",
-		"internal server error",
-		"bad gateway",
-		"gateway timeout",
-		"temporary",
-		"retry",
-		"response error",
-	}
-
-	for _, pattern := range retryablePatterns {
-		if strings.Contains(errStr, pattern) {
-			return true
-		}
+// firstBack 取第一个有效的备用翻译器
+func firstBack(backs []Translate) Translate {
+	if len(backs) == 0 || isNilTranslate(backs[0]) {
+		return nil
 	}
-
-	return false
+	return backs[0]
 }
 
 // calculateDelay 计算重试延迟时间（指数退避）
 func (t *TranOpenai) calculateDelay(attempt int) time.Duration {
-	delay := float64(t.retryConfig.BaseDelay) * math.Pow(t.retryConfig.BackoffFactor, float64(attempt))
-	if delay > float64(t.retryConfig.MaxDelay) {
-		delay = float64(t.retryConfig.MaxDelay)
-	}
-	return time.Duration(delay)
+	return t.retryConfig.delay(attempt)
 }
 
 // translateWithRetry 带重试的翻译方法
 func (t *TranOpenai) translateWithRetry(req *TranReq) (Paragraph, error) {
-	var lastErr error
-
-	for attempt := 0; attempt <= t.retryConfig.MaxRetries; attempt++ {
-		// 如果不是第一次尝试，等待一段时间
-		if attempt > 0 {
-			delay := t.calculateDelay(attempt - 1)
-			if t.logger != nil {
-				t.logger.Info("重试翻译，第 %d 次尝试，等待 %v", attempt, delay)
-			}
-			time.Sleep(delay)
-		}
-
-		// 记录翻译尝试
-		if t.logger != nil {
-			if attempt == 0 {
-				t.logger.Debug("开始翻译请求")
-			} else {
-				t.logger.Info("重试翻译，第 %d 次尝试", attempt)
-			}
-		}
-
-		// 尝试翻译
-		result, err := t.performTranslation(req)
-		if err == nil {
-			if t.logger != nil {
-				t.logger.Debug("翻译成功")
-			}
-			return result, nil
-		}
-
-		lastErr = err
-
-		// 记录错误
-		if t.logger != nil {
-			t.logger.Warn("翻译失败，第 %d 次尝试，错误: %v", attempt+1, err)
-		}
-
-		// 检查是否应该重试
-		if !isRetryableError(err) {
-			if t.logger != nil {
-				t.logger.Warn("错误不可重试，停止重试: %v", err)
-			}
-			break
-		}
-
-		// 如果还有重试机会，继续
-		if attempt < t.retryConfig.MaxRetries {
-			if t.logger != nil {
-				t.logger.Info("错误可重试，准备重试，剩余重试次数: %d", t.retryConfig.MaxRetries-attempt)
-			}
-			continue
-		}
+	result, lastErr := retryDo(t.retryConfig, t.logger, req.OnRetry, func() (Paragraph, error) {
+		return callWithBreaker(t.breaker, t.logger, func() (Paragraph, error) {
+			return t.performTranslation(req)
+		})
+	})
+	if lastErr == nil {
+		return result, nil
 	}
 
 	// 如果所有重试都失败了，尝试备用翻译器
 	if t.back != nil {
 		if t.logger != nil {
-			t.logger.Info("所有重试失败，尝试备用翻译器")
+			if errors.Is(lastErr, ErrCircuitOpen) {
+				t.logger.Info("熔断器已打开，直接使用备用翻译器")
+			} else {
+				t.logger.Info("所有重试失败，尝试备用翻译器")
+			}
 		}
 		return t.back.T(req)
 	}
@@ -265,31 +259,84 @@ func (t *TranOpenai) translateWithRetry(req *TranReq) (Paragraph, error) {
 	return nil, lastErr
 }
 
-// performTranslation 执行实际的翻译操作
-func (t *TranOpenai) performTranslation(req *TranReq) (Paragraph, error) {
-	ctx := context.Background()
+// client 获取共享的 LLM 客户端，构建成功后不再重新构建，失败的结果不缓存
+func (t *TranOpenai) client() (*openai.LLM, error) {
+	t.llmMu.Lock()
+	defer t.llmMu.Unlock()
+	if t.llm != nil {
+		return t.llm, nil
+	}
+
+	hc, err := t.buildHTTPClient()
+	if err != nil {
+		return nil, err
+	}
 	llm, err := openai.New(
 		openai.WithBaseURL(t.url),
 		openai.WithModel(t.model),
 		openai.WithToken(t.key),
 		openai.WithAPIType(openai.APITypeOpenAI),
+		openai.WithHTTPClient(hc),
 	)
 	if err != nil {
 		return nil, err
 	}
+	t.llm = llm
+	return llm, nil
+}
+
+// buildHTTPClient 按配置构建 http.Client，并包装上记录错误响应的 Transport
+func (t *TranOpenai) buildHTTPClient() (*http.Client, error) {
+	if t.httpClient != nil {
+		// 复制一份，避免修改调用方的客户端
+		hc := *t.httpClient
+		hc.Transport = &metaTransport{base: hc.Transport}
+		return &hc, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if t.proxy != "" {
+		proxyURL, err := url.Parse(t.proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %w", t.proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if t.tlsConfig != nil {
+		transport.TLSClientConfig = t.tlsConfig
+	}
 
-	msgs := samplePrompt[getLangKey(lang.ZH, lang.EN)]
-	contentMsg := strings.Join(req.Paras, Seq)
-	content := append([]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeSystem, prompt.TranslatePrompt(req.From, req.To, len(req.Paras)))}, msgs...)
-	content = append(content, llms.TextParts(llms.ChatMessageTypeHuman, contentMsg))
-	generateContent, err := llm.GenerateContent(ctx, content)
+	return &http.Client{
+		Transport: &metaTransport{base: transport},
+		Timeout:   t.timeout,
+	}, nil
+}
+
+// performTranslation 执行实际的翻译操作
+func (t *TranOpenai) performTranslation(req *TranReq) (Paragraph, error) {
+	ctx, meta := withRespMeta(context.Background())
+	llm, err := t.client()
 	if err != nil {
 		return nil, err
 	}
 
+	systemPrompt, err := t.systemPrompt(req)
+	if err != nil {
+		return nil, err
+	}
+	content := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt)}
+	content = append(content, t.exampleMessages(req.From, req.To)...)
+	content = append(content, llms.TextParts(llms.ChatMessageTypeHuman, strings.Join(req.Paras, Seq)))
+	model, callOpts := t.callOptions(req)
+	generateContent, err := llm.GenerateContent(ctx, content, callOpts...)
+	if err != nil {
+		return nil, meta.apiError(t.provider(), model, err)
+	}
+
 	if len(generateContent.Choices) == 0 {
 		return nil, fmt.Errorf("no response choices returned from API")
 	}
+	req.AddUsage(model, usageFromInfo(generateContent.Choices[0].GenerationInfo))
 
 	res := strings.Split(generateContent.Choices[0].Content, Seq)
 
@@ -314,12 +361,43 @@ func (t *TranOpenai) performTranslation(req *TranReq) (Paragraph, error) {
 			_ = os.WriteFile(fmt.Sprintf("error_resp_%d.log", time.Now().Unix()), []byte(s.String()), 0644)
 			t.logger.Warn("翻译结果段落数与请求段落数不匹配，可能存在部分翻译丢失，req:%d, res:%d", len(req.Paras), len(res))
 		}
-		return res, fmt.Errorf("response error，req:%d!=res:%d", len(req.Paras), len(res))
+		return res, fmt.Errorf("%w，req:%d!=res:%d", ErrSegmentMismatch, len(req.Paras), len(res))
 	}
 
 	return res, nil
 }
 
+// Summarize 生成文档摘要，失败时按重试配置重试，不使用备用翻译器
+func (t *TranOpenai) Summarize(req *SummaryReq) (string, error) {
+	res, err := retryDo(t.retryConfig, t.logger, nil, func() (Paragraph, error) {
+		return callWithBreaker(t.breaker, t.logger, func() (Paragraph, error) {
+			ctx, meta := withRespMeta(context.Background())
+			llm, err := t.client()
+			if err != nil {
+				return nil, err
+			}
+
+			content := []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeSystem, prompt.SummaryPrompt(req.Lang)),
+				llms.TextParts(llms.ChatMessageTypeHuman, req.Text),
+			}
+			resp, err := llm.GenerateContent(ctx, content)
+			if err != nil {
+				return nil, meta.apiError(t.provider(), t.model, err)
+			}
+			if len(resp.Choices) == 0 {
+				return nil, fmt.Errorf("no response choices returned from API")
+			}
+			req.AddUsage(t.model, usageFromInfo(resp.Choices[0].GenerationInfo))
+			return Paragraph{strings.TrimSpace(resp.Choices[0].Content)}, nil
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	return res[0], nil
+}
+
 func (t *TranOpenai) T(req *TranReq) (Paragraph, error) {
 	return t.translateWithRetry(req)
 }
@@ -328,17 +406,110 @@ func (t *TranOpenai) Name() string {
 	return "OpenAI"
 }
 
-func getLangKey(form, to string) string {
-	return fmt.Sprintf("%s_%s", form, to)
+// provider 服务商名，直接构造时使用翻译器名
+func (t *TranOpenai) provider() string {
+	if t.source != "" {
+		return t.source
+	}
+	return t.Name()
+}
+
+// Model 使用的模型名，用于选择分词器和上下文限制
+func (t *TranOpenai) Model() string {
+	return t.model
+}
+
+// usageFromInfo 从 langchaingo 的生成信息中读取用量
+func usageFromInfo(info map[string]any) Usage {
+	toInt := func(key string) int {
+		switch v := info[key].(type) {
+		case int:
+			return v
+		case int64:
+			return int(v)
+		case float64:
+			return int(v)
+		}
+		return 0
+	}
+
+	u := Usage{
+		Requests:         1,
+		PromptTokens:     toInt("PromptTokens"),
+		CompletionTokens: toInt("CompletionTokens"),
+		TotalTokens:      toInt("TotalTokens"),
+	}
+	if u.TotalTokens == 0 {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+	return u
+}
+
+// callOptions 请求使用的模型和调用选项，请求选项优先于领域默认温度
+func (t *TranOpenai) callOptions(req *TranReq) (string, []llms.CallOption) {
+	model := t.model
+	callOpts := make([]llms.CallOption, 0)
+	if req.Options.Model != "" {
+		model = req.Options.Model
+		callOpts = append(callOpts, llms.WithModel(model))
+	}
+	if req.Options.Temperature != nil {
+		callOpts = append(callOpts, llms.WithTemperature(*req.Options.Temperature))
+	} else if temp, ok := DomainTemperature[req.Domain]; ok {
+		callOpts = append(callOpts, llms.WithTemperature(temp))
+	}
+	if req.Options.MaxTokens > 0 {
+		callOpts = append(callOpts, llms.WithMaxTokens(req.Options.MaxTokens))
+	}
+	return model, callOpts
 }
 
-var samplePrompt = map[string][]llms.MessageContent{
-	getLangKey(lang.ZH, lang.EN): {
-		llms.TextParts(llms.ChatMessageTypeHuman, "要运行程序，请使用：`python main.py --input data.json`\n---\n这将处理数据集并生成\n---\n输出文件到`/results/`目录，截止东部时间下午5点。"),
-		llms.TextParts(llms.ChatMessageTypeAI, "To run the program, use: `python main.py --input data.json`\n---\nThis will process the dataset and generate\n---\noutput files in `/results/` directory by 5PM EST."),
-	},
-	getLangKey(lang.EN, lang.ZH): {
-		llms.TextParts(llms.ChatMessageTypeHuman, "To run the program, use: `python main.py --input data.json`\n---\nThis will process the dataset and generate\n---\noutput files in `/results/` directory by 5PM EST."),
-		llms.TextParts(llms.ChatMessageTypeAI, "要运行程序，请使用：`python main.py --input data.json`\n---\n这将处理数据集并生成\n---\n输出文件到`/results/`目录，截止东部时间下午5点。"),
-	},
+// systemPrompt 用模板渲染系统提示词，包含风格要求、片段说明、术语表和文档上下文
+func (t *TranOpenai) systemPrompt(req *TranReq) (string, error) {
+	tmpl := t.template
+	if tmpl == nil {
+		tmpl = prompt.Default
+	}
+
+	res, err := tmpl.Render(promptVars(req))
+	if err != nil {
+		return "", fmt.Errorf("render prompt template %s: %w", tmpl.Name(), err)
+	}
+	return res, nil
+}
+
+// Prompt 请求中原文以外发送给模型的文本：系统提示词和少样本示例
+func (t *TranOpenai) Prompt(req *TranReq) (string, error) {
+	system, err := t.systemPrompt(req)
+	if err != nil {
+		return "", err
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(system)
+	for _, msg := range t.exampleMessages(req.From, req.To) {
+		for _, part := range msg.Parts {
+			if text, ok := part.(llms.TextContent); ok {
+				builder.WriteString("\n")
+				builder.WriteString(text.Text)
+			}
+		}
+	}
+	return builder.String(), nil
+}
+
+// exampleMessages 语言对的少样本示例，每个示例是一问一答两条消息
+func (t *TranOpenai) exampleMessages(from, to string) []llms.MessageContent {
+	examples := t.examples
+	if examples == nil {
+		examples = prompt.DefaultExamples
+	}
+
+	msgs := make([]llms.MessageContent, 0)
+	for _, ex := range examples.For(from, to) {
+		msgs = append(msgs,
+			llms.TextParts(llms.ChatMessageTypeHuman, strings.Join(ex.Source, Seq)),
+			llms.TextParts(llms.ChatMessageTypeAI, strings.Join(ex.Target, Seq)))
+	}
+	return msgs
 }
@@ -0,0 +1,271 @@
+package translate
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/prompt"
+)
+
+// countTransport 统计请求次数的 Transport
+type countTransport struct {
+	n atomic.Int32
+}
+
+func (c *countTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.n.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newChatServer 创建返回固定翻译结果的 OpenAI 兼容服务
+func newChatServer(t *testing.T, content string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"test-model","choices":[{"index":0,` +
+			`"message":{"role":"assistant","content":"` + content + `"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTranOpenai_ClientReuse(t *testing.T) {
+	srv := newChatServer(t, "你好")
+	counter := &countTransport{}
+	userClient := &http.Client{Transport: counter}
+
+	tr := &TranOpenai{url: srv.URL, key: "test", model: "test-model", retryConfig: NoRetry}
+	WithHTTPClient(userClient)(tr)
+
+	first, err := tr.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := tr.T(&TranReq{From: lang.EN, To: lang.ZH, Paras: Paragraph{"Hello"}})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if res[0] != "你好" {
+				t.Errorf("Unexpected result %v", res)
+			}
+		}()
+	}
+	wg.Wait()
+
+	again, _ := tr.client()
+	if first != again {
+		t.Error("Expected the LLM client to be built only once")
+	}
+	if counter.n.Load() != 8 {
+		t.Errorf("Expected custom transport to serve 8 requests, got %d", counter.n.Load())
+	}
+	if userClient.Transport != counter {
+		t.Error("Expected caller's http.Client not to be modified")
+	}
+}
+
+func TestTranOpenai_InvalidProxy(t *testing.T) {
+	tr := &TranOpenai{url: "http://127.0.0.1", key: "test", model: "test-model", retryConfig: NoRetry}
+	WithProxy("://bad")(tr)
+
+	if _, err := tr.T(&TranReq{From: lang.EN, To: lang.ZH, Paras: Paragraph{"Hello"}}); err == nil {
+		t.Error("Expected invalid proxy error")
+	}
+
+	// 构建失败不缓存，修正配置后下次请求重新构建
+	WithProxy("")(tr)
+	if llm, err := tr.client(); err != nil || llm == nil {
+		t.Errorf("Expected client to be rebuilt, got %v", err)
+	}
+}
+
+func TestNewOpenaiWithOpts(t *testing.T) {
+	logger := &MockLogger{}
+	back := NewMockTran()
+	tr := NewOpenaiWithOpts(OpenRouter, WithOpenaiLogger(logger), WithRetryConfig(NoRetry), WithBack(back))
+	if tr == nil {
+		t.Fatal("Expected non-nil translator")
+	}
+	if tr.logger != logger || tr.retryConfig.MaxRetries != 0 || tr.back != back {
+		t.Errorf("Options not applied: %+v", tr)
+	}
+	if NewOpenaiWithOpts("unknown") != nil {
+		t.Error("Expected nil for unknown source")
+	}
+}
+
+func TestTranOpenai_Glossary(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"test-model","choices":[{"index":0,` +
+			`"message":{"role":"assistant","content":"你好"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	tr := &TranOpenai{url: srv.URL, key: "test", model: "test-model", retryConfig: NoRetry}
+	_, err := tr.T(&TranReq{From: lang.EN, To: lang.ZH, Paras: Paragraph{"Hello eden"}, Glossary: map[string]string{"eden": "伊甸"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "- eden: 伊甸") {
+		t.Errorf("Expected glossary in prompt, got %s", body)
+	}
+}
+
+func TestTranOpenai_Context(t *testing.T) {
+	var bodies []string
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"test-model","choices":[{"index":0,` +
+			`"message":{"role":"assistant","content":"A user guide."},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`))
+	}))
+	defer srv.Close()
+
+	tr := &TranOpenai{url: srv.URL, key: "test", model: "test-model", retryConfig: NoRetry}
+	sreq := &SummaryReq{Text: "Eden translates documents.", Lang: lang.EN}
+	summary, err := tr.Summarize(sreq)
+	if err != nil || summary != "A user guide." {
+		t.Fatalf("Summarize() = %q, %v", summary, err)
+	}
+	if sreq.Usage.Total().TotalTokens != 12 {
+		t.Errorf("Unexpected summary usage %+v", sreq.Usage)
+	}
+
+	_, err = tr.T(&TranReq{From: lang.EN, To: lang.ZH, Paras: Paragraph{"It works."}, Context: &DocContext{
+		Summary:  summary,
+		Previous: []Pair{{Source: "Install eden.", Target: "安装 eden。"}, {Source: "Run it."}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## Document Context", "A user guide.", "- Source: Install eden.", "Translation: 安装 eden。", "- Source: Run it."} {
+		if !strings.Contains(bodies[1], want) {
+			t.Errorf("Expected %q in prompt, got %s", want, bodies[1])
+		}
+	}
+}
+
+func TestTranOpenai_PromptAndExamples(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"test-model","choices":[{"index":0,` +
+			`"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	tr := &TranOpenai{url: srv.URL, key: "test", model: "test-model", retryConfig: NoRetry}
+	send := func(from, to string) string {
+		t.Helper()
+		if _, err := tr.T(&TranReq{From: from, To: to, Paras: Paragraph{"x"}}); err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	// 中英示例只用于中英语言对
+	if got := send(lang.ZH, lang.EN); !strings.Contains(got, "python main.py") {
+		t.Errorf("Expected ZH->EN examples, got %s", got)
+	}
+	if got := send(lang.KO, lang.RU); strings.Contains(got, "python main.py") {
+		t.Errorf("Unexpected examples for KO->RU: %s", got)
+	}
+
+	tmpl, err := prompt.NewTemplate("custom", "Custom prompt {{.fromLang}}->{{.toLang}} tone={{.tone}}", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithPromptTemplate(tmpl)(tr)
+	WithExamples(prompt.NewExamples(prompt.Example{From: lang.KO, To: lang.RU, Source: []string{"안녕"}, Target: []string{"Привет"}}))(tr)
+	if _, err := tr.T(&TranReq{From: lang.KO, To: lang.RU, Tone: "formal", Paras: Paragraph{"x"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Custom prompt Korean-\\u003eRussian tone=formal", "Привет"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected %q in request, got %s", want, body)
+		}
+	}
+}
+
+func TestTranOpenai_Style(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"test-model","choices":[{"index":0,` +
+			`"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	tr := &TranOpenai{url: srv.URL, key: "test", model: "test-model", retryConfig: NoRetry}
+	_, err := tr.T(&TranReq{From: lang.EN, To: lang.ZH, Domain: DomainLegal, Formality: FormalityFormal,
+		Locale: "zh-TW", Audience: "lawyers", Paras: Paragraph{"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"temperature":0.1`, "## Style", "Traditional Chinese", "lawyers"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected %q in request, got %s", want, body)
+		}
+	}
+
+	if _, err := tr.T(&TranReq{From: lang.EN, To: lang.ZH, Paras: Paragraph{"x"}}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), "## Style") {
+		t.Errorf("Unexpected style section without style, got %s", body)
+	}
+}
+
+func TestTranOpenai_RequestOptions(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"other-model","choices":[{"index":0,` +
+			`"message":{"role":"assistant","content":"a\n---\nb"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`))
+	}))
+	defer srv.Close()
+
+	tr := &TranOpenai{url: srv.URL, key: "test", model: "test-model", retryConfig: NoRetry}
+	temp := 0.5
+	req := &TranReq{From: lang.EN, To: lang.ZH, Domain: DomainLegal, Paras: Paragraph{"Intro", "Price"},
+		Segments: []Segment{{ID: "p0.s0", Style: "heading 1"}, {ID: "p9.s0", Style: "Normal", TableCell: true}},
+		Options:  ReqOptions{Model: "other-model", Temperature: &temp, MaxTokens: 100}}
+	if _, err := tr.T(req); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"model":"other-model"`, `"temperature":0.5`, `"max_completion_tokens":100`,
+		"Segment 1: heading 1", "Segment 2: table cell"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected %q in request, got %s", want, body)
+		}
+	}
+	if _, ok := req.Usage["other-model"]; !ok {
+		t.Errorf("Expected usage under the request model, got %v", req.Usage)
+	}
+}
@@ -0,0 +1,210 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gou-jjjj/eden/logger"
+)
+
+// RetryConfig 重试配置
+type RetryConfig struct {
+	MaxRetries    int           // 最大重试次数
+	BaseDelay     time.Duration // 基础延迟时间
+	MaxDelay      time.Duration // 最大延迟时间
+	BackoffFactor float64       // 退避因子
+	Jitter        float64       // 随机抖动比例，0.2 表示在 ±20% 范围内浮动
+}
+
+// DefaultRetryConfig 默认重试配置
+var DefaultRetryConfig = RetryConfig{
+	MaxRetries:    3,
+	BaseDelay:     time.Second,
+	MaxDelay:      30 * time.Second,
+	BackoffFactor: 2.0,
+	Jitter:        0.2,
+}
+
+// NoRetry 不重试，失败后直接返回
+var NoRetry = RetryConfig{}
+
+// delay 计算第 attempt 次重试前的等待时间（指数退避加随机抖动）
+func (c RetryConfig) delay(attempt int) time.Duration {
+	delay := float64(c.BaseDelay) * math.Pow(c.BackoffFactor, float64(attempt))
+	if c.Jitter > 0 {
+		delay *= 1 + c.Jitter*(rand.Float64()*2-1)
+	}
+	if delay > float64(c.MaxDelay) {
+		delay = float64(c.MaxDelay)
+	}
+	return time.Duration(delay)
+}
+
+// retryDelay 根据上次错误决定等待多久，服务端给出的 Retry-After 优先，最长等待 MaxDelay
+func (c RetryConfig) retryDelay(attempt int, lastErr error) time.Duration {
+	var apiErr *APIError
+	if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+		if c.MaxDelay > 0 && apiErr.RetryAfter > c.MaxDelay {
+			return c.MaxDelay
+		}
+		return apiErr.RetryAfter
+	}
+	return c.delay(attempt)
+}
+
+// retryablePatterns 无法识别类型的错误按错误信息兜底判断
+var retryablePatterns = []string{
+	"timeout",
+	"connection refused",
+	"connection reset",
+	"network is unreachable",
+	"rate limit",
+	"too many requests",
+	"service unavailable",
+	"internal server error",
+	"bad gateway",
+	"gateway timeout",
+}
+
+// isRetryableError 判断错误是否可重试
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch {
+	case errors.Is(err, ErrCircuitOpen), errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, ErrSegmentMismatch),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED):
+		return true
+	}
+
+	// 服务端返回的 HTTP 错误按状态码判断
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+
+	// 网络超时
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	errStr := strings.ToLower(err.Error())
+	for _, pattern := range retryablePatterns {
+		if strings.Contains(errStr, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryDo 按重试配置反复执行 fn，直到成功、遇到不可重试错误或用尽重试次数
+// 每次重试前调用 onRetry，可以为空
+func retryDo(cfg RetryConfig, lg logger.Logger, onRetry func(int, error), fn func() (Paragraph, error)) (Paragraph, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
+		// 如果不是第一次尝试，等待一段时间
+		if attempt > 0 {
+			delay := cfg.retryDelay(attempt-1, lastErr)
+			if lg != nil {
+				lg.Info("重试翻译，第 %d 次尝试，等待 %v", attempt, delay)
+			}
+			if onRetry != nil {
+				onRetry(attempt, lastErr)
+			}
+			time.Sleep(delay)
+		}
+
+		// 记录翻译尝试
+		if lg != nil {
+			if attempt == 0 {
+				lg.Debug("开始翻译请求")
+			} else {
+				lg.Info("重试翻译，第 %d 次尝试", attempt)
+			}
+		}
+
+		// 尝试翻译
+		result, err := fn()
+		if err == nil {
+			if lg != nil {
+				lg.Debug("翻译成功")
+			}
+			return result, nil
+		}
+
+		lastErr = err
+
+		// 记录错误
+		if lg != nil {
+			lg.Warn("翻译失败，第 %d 次尝试，错误: %v", attempt+1, err)
+		}
+
+		// 检查是否应该重试
+		if !isRetryableError(err) {
+			if lg != nil {
+				lg.Warn("错误不可重试，停止重试: %v", err)
+			}
+			break
+		}
+
+		// 如果还有重试机会，继续
+		if attempt < cfg.MaxRetries {
+			if lg != nil {
+				lg.Info("错误可重试，准备重试，剩余重试次数: %d", cfg.MaxRetries-attempt)
+			}
+			continue
+		}
+	}
+
+	return nil, lastErr
+}
+
+// RetryTran 为任意翻译器附加重试策略
+type RetryTran struct {
+	tran        Translate
+	retryConfig RetryConfig
+	logger      logger.Logger
+}
+
+// NewRetry 创建带重试策略的翻译器
+func NewRetry(tran Translate, retryConfig RetryConfig, logger ...logger.Logger) *RetryTran {
+	r := &RetryTran{
+		tran:        tran,
+		retryConfig: retryConfig,
+	}
+	if len(logger) > 0 {
+		r.logger = logger[0]
+	}
+	return r
+}
+
+func (r *RetryTran) T(req *TranReq) (Paragraph, error) {
+	res, err := retryDo(r.retryConfig, r.logger, req.OnRetry, func() (Paragraph, error) {
+		return r.tran.T(req)
+	})
+	if err != nil && r.logger != nil {
+		r.logger.Error("翻译失败，已用尽所有重试次数，最后错误: %v", err)
+	}
+	return res, err
+}
+
+func (r *RetryTran) Name() string {
+	return fmt.Sprintf("Retry(%s)", r.tran.Name())
+}
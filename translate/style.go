@@ -0,0 +1,30 @@
+package translate
+
+// 文档领域，提示词会给出对应的翻译要求，也可以用 Router.Domain 按领域选择翻译器
+const (
+	DomainLegal     = "legal"
+	DomainMedical   = "medical"
+	DomainTechnical = "technical"
+	DomainMarketing = "marketing"
+)
+
+// Domains 内置翻译要求的文档领域
+var Domains = []string{DomainLegal, DomainMedical, DomainTechnical, DomainMarketing}
+
+// 正式程度
+const (
+	FormalityFormal   = "formal"
+	FormalityInformal = "informal"
+)
+
+// Formalities 支持的正式程度
+var Formalities = []string{FormalityFormal, FormalityInformal}
+
+// DomainTemperature 各领域使用的采样温度，准确性要求高的领域更低，营销文案更高
+// 不在表中的领域使用服务商默认温度
+var DomainTemperature = map[string]float64{
+	DomainLegal:     0.1,
+	DomainMedical:   0.1,
+	DomainTechnical: 0.2,
+	DomainMarketing: 0.7,
+}
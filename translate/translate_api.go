@@ -1,11 +1,35 @@
 package translate
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gou-jjjj/eden/prompt"
+)
+
 type Paragraph []string
 
 type TranReq struct {
-	From  string    `json:"from"`
-	To    string    `json:"to"`
-	Paras Paragraph `json:"paras"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Domain string    `json:"domain,omitempty"` // 文档领域，见 Domains，也供路由器选择翻译器
+	Paras  Paragraph `json:"paras"`
+
+	Tone      string `json:"tone,omitempty"`      // 语气，如 friendly、persuasive
+	Formality string `json:"formality,omitempty"` // 正式程度，见 Formalities
+	Locale    string `json:"locale,omitempty"`    // 目标语言的地区变体，如 zh-TW、en-GB、pt-BR
+	Audience  string `json:"audience,omitempty"`  // 目标读者，如 developers、patients
+
+	Glossary map[string]string `json:"glossary,omitempty"` // 术语表，原文术语到译文的映射，只包含本次请求出现的术语
+	Context  *DocContext       `json:"context,omitempty"`  // 文档摘要和之前的原文译文，可以为空
+
+	Segments []Segment  `json:"segments,omitempty"` // 片段的来源信息，与 Paras 一一对应，可以为空
+	Meta     JobMeta    `json:"meta"`               // 请求所属的任务和文档
+	Options  ReqOptions `json:"options"`            // 单次请求的选项
+
+	Usage   UsageByModel                 `json:"-"` // 翻译器上报的用量，重试和备用翻译器的调用会累加
+	OnRetry func(attempt int, err error) `json:"-"` // 翻译器重试前的回调，可以为空
 }
 
 type Translate interface {
@@ -13,6 +37,56 @@ type Translate interface {
 	Name() string
 }
 
+// ModelTranslate 基于大模型的翻译器，可提供模型名
+type ModelTranslate interface {
+	Translate
+	Model() string
+}
+
+// PromptTranslate 基于提示词的翻译器，可提供请求中原文以外发送给模型的文本，用于计算分块预算
+type PromptTranslate interface {
+	Translate
+	Prompt(req *TranReq) (string, error)
+}
+
+// PromptFor 请求中原文以外发送给模型的文本，翻译器不提供时按默认模板渲染
+func PromptFor(tran Translate, req *TranReq) string {
+	if pt, ok := tran.(PromptTranslate); ok {
+		if res, err := pt.Prompt(req); err == nil {
+			return res
+		}
+	}
+
+	res, err := prompt.Default.Render(promptVars(req))
+	if err != nil {
+		return ""
+	}
+	return res
+}
+
+// promptVars 请求对应的提示词模板变量
+func promptVars(req *TranReq) prompt.Vars {
+	vars := prompt.Vars{
+		FromLang: req.From,
+		ToLang:   req.To,
+		Segments: len(req.Paras),
+		Glossary: req.Glossary,
+		Notes:    req.Notes(),
+		Style: prompt.Style{
+			Domain:    req.Domain,
+			Tone:      req.Tone,
+			Formality: req.Formality,
+			Locale:    req.Locale,
+			Audience:  req.Audience,
+		},
+	}
+	if req.Context != nil {
+		vars.Summary = req.Context.Summary
+		vars.Previous = req.Context.Previous
+	}
+	return vars
+}
+
 const (
 	BaiDu = "baidu"
 )
@@ -21,6 +95,31 @@ const (
 	OpenAI = "openai"
 )
 
+// Mock 模拟翻译器名称，原样返回原文，用于测试
+const Mock = "mock"
+
 var TranslateSet = map[string]Translate{
 	//BaiDu: NewBaidu("http://api.fanyi.baidu.com/api/trans/vip/translate", "20220422001184836", "kRMl9t9LwAn7EFCLibz0"),
 }
+
+// Providers 可以按名称创建的翻译器，按名称排序
+func Providers() []string {
+	names := make([]string, 0, len(OpenaiModelList)+1)
+	for name := range OpenaiModelList {
+		names = append(names, name)
+	}
+	names = append(names, Mock)
+	sort.Strings(names)
+	return names
+}
+
+// NewByName 按名称创建翻译器，opts 只对大模型翻译器生效
+func NewByName(name string, opts ...OpenaiOpt) (Translate, error) {
+	if name == Mock {
+		return NewMockTran(), nil
+	}
+	if _, ok := OpenaiModelList[name]; !ok {
+		return nil, fmt.Errorf("unknown provider %q, available: %s", name, strings.Join(Providers(), ", "))
+	}
+	return NewOpenaiWithOpts(name, opts...), nil
+}
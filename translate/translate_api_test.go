@@ -0,0 +1,55 @@
+package translate
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/prompt"
+)
+
+func TestNewByName(t *testing.T) {
+	tran, err := NewByName(Mock)
+	if err != nil || tran.Name() != "Mock" {
+		t.Fatalf("Unexpected mock translator %v, %v", tran, err)
+	}
+
+	tran, err = NewByName(ZhiPu, WithTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := tran.(ModelTranslate); !ok || m.Model() != OpenaiModelList[ZhiPu].Model {
+		t.Errorf("Expected model translator for %s", ZhiPu)
+	}
+
+	if _, err := NewByName("nope"); err == nil {
+		t.Error("Expected error for unknown provider")
+	}
+}
+
+func TestPromptFor(t *testing.T) {
+	req := &TranReq{From: lang.KO, To: lang.RU, Domain: DomainLegal, Paras: Paragraph{"x"},
+		Glossary: map[string]string{"contract": "договор"}}
+
+	mock := PromptFor(NewMockTran(), req)
+	if !strings.Contains(mock, "договор") || !strings.Contains(mock, "## Style") {
+		t.Errorf("Expected default prompt with glossary and style, got %s", mock)
+	}
+
+	tr := &TranOpenai{model: "test-model"}
+	WithExamples(prompt.NewExamples(prompt.Example{From: lang.KO, To: lang.RU, Source: []string{"안녕"}, Target: []string{"Привет"}}))(tr)
+	openai := PromptFor(tr, req)
+	if !strings.Contains(openai, "안녕") || !strings.Contains(openai, "Привет") || len(openai) <= len(mock) {
+		t.Errorf("Expected prompt with few-shot examples, got %s", openai)
+	}
+
+	// 包装的翻译器按成员中最长的提示词计算
+	wrapped := NewBreakerTran(NewLimitTran(NewChain(NewMockTran(), tr), nil), NewBreaker("prompt", BreakerConfig{}))
+	if got := PromptFor(wrapped, req); got != openai {
+		t.Errorf("Wrapped prompt %q, want %q", got, openai)
+	}
+	if got := PromptFor(NewRouter(nil).LangPair(lang.All, lang.RU, tr), req); got != openai {
+		t.Errorf("Router prompt %q, want %q", got, openai)
+	}
+}
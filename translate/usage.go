@@ -0,0 +1,96 @@
+package translate
+
+import "sort"
+
+// Usage token 用量
+type Usage struct {
+	Requests         int `json:"requests"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Add 累加用量
+func (u *Usage) Add(o Usage) {
+	u.Requests += o.Requests
+	u.PromptTokens += o.PromptTokens
+	u.CompletionTokens += o.CompletionTokens
+	u.TotalTokens += o.TotalTokens
+}
+
+// UsageByModel 按模型统计的用量
+type UsageByModel map[string]Usage
+
+// Add 累加某个模型的用量
+func (m UsageByModel) Add(model string, u Usage) {
+	cur := m[model]
+	cur.Add(u)
+	m[model] = cur
+}
+
+// Merge 合并另一份用量统计
+func (m UsageByModel) Merge(o UsageByModel) {
+	for model, u := range o {
+		m.Add(model, u)
+	}
+}
+
+// Total 所有模型的用量合计
+func (m UsageByModel) Total() Usage {
+	total := Usage{}
+	for _, u := range m {
+		total.Add(u)
+	}
+	return total
+}
+
+// Models 按名字排序的模型列表
+func (m UsageByModel) Models() []string {
+	models := make([]string, 0, len(m))
+	for model := range m {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+// Price 模型单价，单位：美元/百万 token
+type Price struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// Cost 计算用量费用
+func (p Price) Cost(u Usage) float64 {
+	return (float64(u.PromptTokens)*p.Prompt + float64(u.CompletionTokens)*p.Completion) / 1e6
+}
+
+// PriceTable 模型价格表
+type PriceTable map[string]Price
+
+// DefaultPriceTable 默认价格表，仅供参考，以服务商实际价格为准
+var DefaultPriceTable = PriceTable{
+	"gpt-4o":                {Prompt: 2.5, Completion: 10},
+	"gpt-4o-mini":           {Prompt: 0.15, Completion: 0.6},
+	"glm-4-plus":            {Prompt: 0.7, Completion: 0.7},
+	"deepseek-v3":           {Prompt: 0.27, Completion: 1.1},
+	"qwen-plus":             {Prompt: 0.4, Completion: 1.2},
+	"x-ai/grok-4-fast:free": {Prompt: 0, Completion: 0},
+}
+
+// Cost 计算用量费用，价格表中没有的模型按 0 计算
+func (t PriceTable) Cost(usage UsageByModel) float64 {
+	cost := 0.0
+	for model, u := range usage {
+		cost += t[model].Cost(u)
+	}
+	return cost
+}
+
+// AddUsage 翻译器上报一次调用的用量
+func (r *TranReq) AddUsage(model string, u Usage) {
+	if r.Usage == nil {
+		r.Usage = UsageByModel{}
+	}
+	r.Usage.Add(model, u)
+}
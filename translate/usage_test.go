@@ -0,0 +1,48 @@
+package translate
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gou-jjjj/eden/lang"
+)
+
+func TestUsageByModel_Cost(t *testing.T) {
+	usage := UsageByModel{}
+	usage.Add("a", Usage{Requests: 1, PromptTokens: 1000000, CompletionTokens: 500000})
+	usage.Add("a", Usage{Requests: 1, PromptTokens: 1000000})
+	usage.Add("b", Usage{Requests: 1, CompletionTokens: 1000000})
+	usage.Add("free", Usage{Requests: 1, PromptTokens: 10})
+
+	prices := PriceTable{
+		"a": {Prompt: 1, Completion: 2},
+		"b": {Prompt: 1, Completion: 4},
+	}
+	if got := prices.Cost(usage); math.Abs(got-7) > 1e-9 {
+		t.Errorf("Cost() = %v, want 7", got)
+	}
+
+	total := usage.Total()
+	if total.Requests != 4 || total.PromptTokens != 2000010 || total.CompletionTokens != 1500000 {
+		t.Errorf("Unexpected total %+v", total)
+	}
+	if models := usage.Models(); len(models) != 3 || models[0] != "a" {
+		t.Errorf("Unexpected models %v", models)
+	}
+}
+
+func TestTranOpenai_ReportUsage(t *testing.T) {
+	srv := newChatServer(t, "你好")
+	tr := &TranOpenai{url: srv.URL, key: "test", model: "test-model", retryConfig: NoRetry}
+
+	req := &TranReq{From: lang.EN, To: lang.ZH, Paras: Paragraph{"Hello"}}
+	if _, err := tr.T(req); err != nil {
+		t.Fatal(err)
+	}
+
+	got := req.Usage["test-model"]
+	want := Usage{Requests: 1, PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}
+	if got != want {
+		t.Errorf("Usage = %+v, want %+v", got, want)
+	}
+}
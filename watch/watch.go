@@ -0,0 +1,356 @@
+// Package watch 监视收件目录，翻译放入的文档，原文按结果移到 done 或 failed 目录
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gou-jjjj/eden"
+	"github.com/gou-jjjj/eden/config"
+	"github.com/gou-jjjj/eden/logger"
+)
+
+const (
+	// DefaultInterval 默认扫描间隔
+	DefaultInterval = 2 * time.Second
+	// DefaultSettle 文件大小和修改时间保持不变多久后才认为写入完成
+	DefaultSettle = 5 * time.Second
+	// ReportSuffix 报告文件的后缀，报告与原文放在同一目录
+	ReportSuffix = ".report.json"
+)
+
+// Opt 监视选项
+type Opt func(*Watcher)
+
+// WithInterval 扫描间隔
+func WithInterval(d time.Duration) Opt {
+	return func(w *Watcher) {
+		w.interval = d
+	}
+}
+
+// WithSettle 文件保持不变的时间，用于跳过正在写入的文件
+func WithSettle(d time.Duration) Opt {
+	return func(w *Watcher) {
+		w.settle = d
+	}
+}
+
+// WithDoneDir 翻译成功的原文移到 dir，默认为收件目录下的 done
+func WithDoneDir(dir string) Opt {
+	return func(w *Watcher) {
+		w.doneDir = dir
+	}
+}
+
+// WithFailedDir 翻译失败的原文移到 dir，默认为收件目录下的 failed
+func WithFailedDir(dir string) Opt {
+	return func(w *Watcher) {
+		w.failedDir = dir
+	}
+}
+
+// WithDocxOptions 额外的处理器选项，在任务配置之后应用
+func WithDocxOptions(opts ...eden.Opt) Opt {
+	return func(w *Watcher) {
+		w.opts = append(w.opts, opts...)
+	}
+}
+
+// WithResultHandler 每个文件处理完成后的回调
+func WithResultHandler(h func(Result)) Opt {
+	return func(w *Watcher) {
+		w.handler = h
+	}
+}
+
+// WithLogger 记录扫描和移动文件的错误，这些错误不会中止监视
+func WithLogger(l logger.Logger) Opt {
+	return func(w *Watcher) {
+		w.logger = l
+	}
+}
+
+// Result 单个文件的处理结果
+type Result struct {
+	Input  string       // 收件目录中的原文路径
+	Moved  string       // 原文移动后的路径
+	Report *eden.Report // 处理报告，加载失败时为空
+	Err    error
+}
+
+// Sidecar 与原文一起保存的报告
+type Sidecar struct {
+	Input    string       `json:"input"`
+	Status   string       `json:"status"` // done 或 failed
+	Started  time.Time    `json:"started"`
+	Finished time.Time    `json:"finished"`
+	Error    string       `json:"error,omitempty"`
+	Report   *eden.Report `json:"report,omitempty"`
+}
+
+// fileState 文件上次扫描时的状态
+type fileState struct {
+	size  int64
+	mod   time.Time
+	since time.Time // 状态保持不变的起始时间
+}
+
+// Watcher 收件目录监视器
+type Watcher struct {
+	inbox     string
+	output    string
+	doneDir   string
+	failedDir string
+	job       *config.Job
+	opts      []eden.Opt
+	interval  time.Duration
+	settle    time.Duration
+	handler   func(Result)
+	logger    logger.Logger
+
+	pending map[string]fileState
+	stuck   map[string]fileState // 处理后无法移出收件目录的文件，内容不变时不再处理
+	now     func() time.Time
+}
+
+// New 创建监视器，译文写入 output，任务配置中的输出目录不再使用
+func New(inbox, output string, job *config.Job, opts ...Opt) (*Watcher, error) {
+	if job == nil {
+		job = &config.Job{}
+	}
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		inbox:     inbox,
+		output:    output,
+		doneDir:   filepath.Join(inbox, "done"),
+		failedDir: filepath.Join(inbox, "failed"),
+		job:       job,
+		interval:  DefaultInterval,
+		settle:    DefaultSettle,
+		pending:   map[string]fileState{},
+		stuck:     map[string]fileState{},
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(w)
+		}
+	}
+
+	info, err := os.Stat(inbox)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", inbox)
+	}
+	for _, dir := range []string{w.output, w.doneDir, w.failedDir} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Run 按间隔扫描收件目录，直到 ctx 结束，扫描失败时记录错误并在下一次继续
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Scan(); err != nil && w.logger != nil {
+			w.logger.Error("扫描收件目录 %s 失败: %v", w.inbox, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan 扫描一次收件目录，处理已写入完成的文件
+func (w *Watcher) Scan() ([]Result, error) {
+	ready, err := w.ready()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(ready))
+	for _, name := range ready {
+		res := w.process(name)
+		if w.handler != nil {
+			w.handler(res)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// ready 收件目录中大小和修改时间已保持 settle 不变的文件
+func (w *Watcher) ready() ([]string, error) {
+	entries, err := os.ReadDir(w.inbox)
+	if err != nil {
+		return nil, err
+	}
+
+	now := w.now()
+	seen := map[string]bool{}
+	ready := make([]string, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !w.match(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// 文件已被移走
+			continue
+		}
+		seen[name] = true
+
+		if st, ok := w.stuck[name]; ok {
+			if st.size == info.Size() && st.mod.Equal(info.ModTime()) {
+				continue
+			}
+			// 文件被替换，重新处理
+			delete(w.stuck, name)
+		}
+
+		st, ok := w.pending[name]
+		if !ok || st.size != info.Size() || !st.mod.Equal(info.ModTime()) {
+			w.pending[name] = fileState{size: info.Size(), mod: info.ModTime(), since: now}
+			if w.settle > 0 {
+				continue
+			}
+		} else if now.Sub(st.since) < w.settle {
+			continue
+		}
+		ready = append(ready, name)
+	}
+
+	for name := range w.pending {
+		if !seen[name] {
+			delete(w.pending, name)
+		}
+	}
+	for name := range w.stuck {
+		if !seen[name] {
+			delete(w.stuck, name)
+		}
+	}
+	return ready, nil
+}
+
+// match 文件名符合任务配置的包含和排除规则
+func (w *Watcher) match(name string) bool {
+	include := []string(w.job.Include)
+	if len(include) == 0 {
+		include = eden.DefaultInclude
+	}
+	exclude := append(append([]string{}, eden.DefaultExclude...), w.job.Exclude...)
+
+	return eden.MatchAny(include, name) && !eden.MatchAny(exclude, name)
+}
+
+// process 翻译文件，移动原文并写入报告
+// 原文无法移出收件目录时记录下来，文件内容不变就不再处理，避免反复翻译
+func (w *Watcher) process(name string) Result {
+	st := w.pending[name]
+	delete(w.pending, name)
+	input := filepath.Join(w.inbox, name)
+	res := Result{Input: input}
+	sidecar := Sidecar{Input: name, Started: w.now()}
+
+	opts, err := w.job.Options()
+	if err == nil {
+		opts = append(opts, w.opts...)
+		opts = append(opts,
+			eden.WithInput(input),
+			eden.WithOutput(w.output),
+			eden.WithName(strings.TrimSuffix(name, filepath.Ext(name))))
+		res.Report, err = eden.NewDocxProcessor(opts...).Process()
+	}
+	res.Err = err
+	sidecar.Finished = w.now()
+	sidecar.Report = res.Report
+
+	dir := w.doneDir
+	sidecar.Status = "done"
+	if err != nil {
+		dir = w.failedDir
+		sidecar.Status = "failed"
+		sidecar.Error = err.Error()
+	}
+
+	moved, err := moveFile(input, dir)
+	if err != nil {
+		w.stuck[name] = st
+		if w.logger != nil {
+			w.logger.Error("移动 %s 到 %s 失败，文件保持不变时不再处理: %v", input, dir, err)
+		}
+		res.Err = errors.Join(res.Err, err)
+		return res
+	}
+	res.Moved = moved
+
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err == nil {
+		err = os.WriteFile(moved+ReportSuffix, data, 0644)
+	}
+	if err != nil {
+		res.Err = errors.Join(res.Err, err)
+	}
+	return res
+}
+
+// moveFile 把文件移到 dir，同名文件已存在时在文件名后加时间戳
+func moveFile(src, dir string) (string, error) {
+	name := filepath.Base(src)
+	dst := filepath.Join(dir, name)
+	if _, err := os.Stat(dst); err == nil {
+		ext := filepath.Ext(name)
+		stamp := time.Now().Format("20060102-150405.000")
+		dst = filepath.Join(dir, fmt.Sprintf("%s_%s%s", strings.TrimSuffix(name, ext), stamp, ext))
+	}
+
+	if err := os.Rename(src, dst); err == nil {
+		return dst, nil
+	}
+
+	// 跨文件系统时复制后删除
+	if err := copyFile(src, dst); err != nil {
+		return "", err
+	}
+	return dst, os.Remove(src)
+}
+
+// copyFile 复制文件内容
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
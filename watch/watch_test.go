@@ -0,0 +1,197 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gou-jjjj/eden/config"
+	"github.com/gou-jjjj/eden/lang"
+	"github.com/gou-jjjj/eden/logger"
+	"github.com/gou-jjjj/eden/translate"
+)
+
+func TestWatcher_Scan(t *testing.T) {
+	src, err := os.ReadFile("../file_examples/Docx4j_GettingStarted.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inbox := t.TempDir()
+	out := t.TempDir()
+	job := &config.Job{Provider: translate.Mock, To: []string{"zh"}}
+	w, err := New(inbox, out, job, WithSettle(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	write := func(name string, data []byte) {
+		if err := os.WriteFile(filepath.Join(inbox, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.docx", src)
+	write("bad.docx", []byte("not a docx"))
+	write("notes.txt", []byte("notes"))
+	write("~$a.docx", []byte("lock"))
+
+	if res, err := w.Scan(); err != nil || len(res) != 0 {
+		t.Fatalf("first scan = %v, %v", res, err)
+	}
+
+	// 写入未完成的文件重新计时
+	now = now.Add(2 * time.Second)
+	write("bad.docx", []byte("still not a docx"))
+	res, err := w.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Err != nil || filepath.Base(res[0].Input) != "a.docx" {
+		t.Fatalf("second scan = %+v", res)
+	}
+	if res[0].Moved != filepath.Join(inbox, "done", "a.docx") {
+		t.Errorf("moved = %s", res[0].Moved)
+	}
+	if _, err := os.Stat(filepath.Join(out, "a_"+lang.LangNames[lang.ZH]+".docx")); err != nil {
+		t.Error(err)
+	}
+
+	var sc Sidecar
+	data, err := os.ReadFile(res[0].Moved + ReportSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &sc); err != nil {
+		t.Fatal(err)
+	}
+	if sc.Status != "done" || sc.Report == nil || sc.Report.Translated == 0 {
+		t.Errorf("Unexpected sidecar %+v", sc)
+	}
+
+	now = now.Add(2 * time.Second)
+	res, err = w.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Err == nil || res[0].Moved != filepath.Join(inbox, "failed", "bad.docx") {
+		t.Fatalf("third scan = %+v", res)
+	}
+	if _, err := os.Stat(res[0].Moved + ReportSuffix); err != nil {
+		t.Error(err)
+	}
+
+	for _, name := range []string{"notes.txt", "~$a.docx"} {
+		if _, err := os.Stat(filepath.Join(inbox, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestWatcher_MoveFailed(t *testing.T) {
+	inbox := t.TempDir()
+	job := &config.Job{Provider: translate.Mock, To: []string{"zh"}}
+	w, err := New(inbox, t.TempDir(), job, WithSettle(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 失败目录是普通文件，原文无法移出收件目录
+	w.failedDir = filepath.Join(t.TempDir(), "failed")
+	if err := os.WriteFile(w.failedDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(inbox, "bad.docx")
+	if err := os.WriteFile(input, []byte("not a docx"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := w.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Err == nil || res[0].Moved != "" {
+		t.Fatalf("first scan = %+v", res)
+	}
+	if res, err := w.Scan(); err != nil || len(res) != 0 {
+		t.Fatalf("File that could not be moved was processed again: %+v, %v", res, err)
+	}
+
+	// 文件被替换后重新处理
+	if err := os.WriteFile(input, []byte("still not a docx"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := w.Scan(); err != nil || len(res) != 1 {
+		t.Fatalf("Replaced file was not processed: %+v, %v", res, err)
+	}
+}
+
+func TestWatcher_RunScanError(t *testing.T) {
+	inbox := t.TempDir()
+	buf := &bytes.Buffer{}
+	job := &config.Job{Provider: translate.Mock, To: []string{"zh"}}
+	w, err := New(inbox, t.TempDir(), job, WithInterval(10*time.Millisecond), WithLogger(logger.NewWriterLogger(buf, false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(inbox); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := w.Run(ctx); err != nil {
+		t.Fatalf("Run() = %v, want scan errors to be logged", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("扫描收件目录")) {
+		t.Errorf("Expected scan error in log, got %s", buf.String())
+	}
+}
+
+func TestWatcher_Match(t *testing.T) {
+	job := &config.Job{Include: []string{"**/*.docx"}, Exclude: []string{"draft-*"}}
+	w, err := New(t.TempDir(), t.TempDir(), job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]bool{
+		"a.docx":       true,
+		"draft-a.docx": false,
+		"~$a.docx":     false,
+		"a.txt":        false,
+	}
+	for name, want := range cases {
+		if got := w.match(name); got != want {
+			t.Errorf("match(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	for i := 0; i < 2; i++ {
+		p := filepath.Join(src, "a.docx")
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		moved, err := moveFile(p, dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 && moved != filepath.Join(dst, "a.docx") {
+			t.Errorf("moved = %s", moved)
+		}
+		if i == 1 && moved == filepath.Join(dst, "a.docx") {
+			t.Error("existing file overwritten")
+		}
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 2 {
+		t.Errorf("%d files in %s", len(entries), dst)
+	}
+}